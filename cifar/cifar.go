@@ -0,0 +1,150 @@
+// Package cifar loads the CIFAR-10 and CIFAR-100 datasets from their binary versions.
+//
+// Each file of the binary versions holds fixed size records, one per image: the label
+// (for CIFAR-10) or the coarse and fine labels (for CIFAR-100) as single bytes, followed
+// by the 32x32 pixels of the red, green and blue channels in turn, each stored row-major.
+//
+// Images are returned as rows of this planar layout scaled to [0, 1], which is the layout
+// nn.ImageShape{Channels: 3, Height: 32, Width: 32} describes, so the rows can be passed
+// straight to convolution layers.
+//
+// The files are only read locally, the datasets must be downloaded and extracted first.
+package cifar
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	Channels = 3
+	Height   = 32
+	Width    = 32
+
+	// ImageSize is the number of values in each image.
+	ImageSize = Channels * Height * Width
+
+	// Classes is the number of classes of CIFAR-10.
+	Classes = 10
+
+	// CoarseClasses and FineClasses are the number of superclasses and classes of CIFAR-100.
+	CoarseClasses = 20
+	FineClasses   = 100
+)
+
+// Load10Files loads the images and one-hot labels of CIFAR-10 from the given files,
+// such as data_batch_1.bin to data_batch_5.bin for the training set, one after another.
+func Load10Files(paths ...string) (x, y *mat.Dense, err error) {
+	x, labels, err := loadFiles(paths, []int{Classes})
+	if err != nil {
+		return nil, nil, err
+	}
+	return x, labels[0], nil
+}
+
+// Load100Files loads the images of CIFAR-100 from the given files, such as train.bin,
+// along with their one-hot coarse labels (one of 20 superclasses) and fine labels (one of 100 classes).
+func Load100Files(paths ...string) (x, coarse, fine *mat.Dense, err error) {
+	x, labels, err := loadFiles(paths, []int{CoarseClasses, FineClasses})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return x, labels[0], labels[1], nil
+}
+
+// Load10 reads the images and one-hot labels of a CIFAR-10 batch file.
+// Returns a matrix where each row contains a new image, and a matrix with 10 columns for the labels.
+func Load10(r io.Reader) (x, y *mat.Dense, err error) {
+	x, labels, err := load(r, []int{Classes})
+	if err != nil {
+		return nil, nil, err
+	}
+	return x, labels[0], nil
+}
+
+// Load100 reads the images, and the one-hot coarse and fine labels, of a CIFAR-100 file.
+func Load100(r io.Reader) (x, coarse, fine *mat.Dense, err error) {
+	x, labels, err := load(r, []int{CoarseClasses, FineClasses})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return x, labels[0], labels[1], nil
+}
+
+func loadFiles(paths []string, classes []int) (*mat.Dense, []*mat.Dense, error) {
+	if len(paths) == 0 {
+		return nil, nil, fmt.Errorf("no files given")
+	}
+
+	var images []float64
+	labels := make([][]int, len(classes))
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		images, labels, err = readRecords(f, classes, images, labels)
+		f.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+
+	return matrices(images, labels, classes)
+}
+
+func load(r io.Reader, classes []int) (*mat.Dense, []*mat.Dense, error) {
+	images, labels, err := readRecords(r, classes, nil, make([][]int, len(classes)))
+	if err != nil {
+		return nil, nil, err
+	}
+	return matrices(images, labels, classes)
+}
+
+// readRecords appends the pixels and labels of each record in r to images and labels.
+// Each record starts with a byte for each of the label sets, where set i has classes[i] classes.
+func readRecords(r io.Reader, classes []int, images []float64, labels [][]int) ([]float64, [][]int, error) {
+	record := make([]byte, len(classes)+ImageSize)
+	for n := 0; ; n++ {
+		_, err := io.ReadFull(r, record)
+		if err == io.EOF {
+			return images, labels, nil
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("record %d: %s", n, err)
+		}
+
+		for i, c := range classes {
+			label := int(record[i])
+			if label >= c {
+				return nil, nil, fmt.Errorf("record %d: invalid label: %d", n, label)
+			}
+			labels[i] = append(labels[i], label)
+		}
+
+		for _, v := range record[len(classes):] {
+			images = append(images, float64(v)/255)
+		}
+	}
+}
+
+func matrices(images []float64, labels [][]int, classes []int) (*mat.Dense, []*mat.Dense, error) {
+	n := len(labels[0])
+	if n == 0 {
+		return nil, nil, fmt.Errorf("no images")
+	}
+
+	oneHot := make([]*mat.Dense, len(classes))
+	for i, c := range classes {
+		oneHot[i] = mat.NewDense(n, c, nil)
+		for row, label := range labels[i] {
+			oneHot[i].Set(row, label, 1)
+		}
+	}
+
+	return mat.NewDense(n, ImageSize, images), oneHot, nil
+}
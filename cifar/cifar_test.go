@@ -0,0 +1,118 @@
+package cifar
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// record returns a record with the given label bytes, whose red, green and blue
+// channels are filled with r, g and b.
+func record(labels []byte, r, g, b byte) []byte {
+	result := append([]byte(nil), labels...)
+	for _, v := range []byte{r, g, b} {
+		result = append(result, bytes.Repeat([]byte{v}, Height*Width)...)
+	}
+	return result
+}
+
+func TestLoad10(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(record([]byte{3}, 255, 0, 51))
+	buf.Write(record([]byte{9}, 0, 102, 0))
+
+	x, y, err := Load10(&buf)
+	require.NoError(t, err)
+
+	rows, cols := x.Dims()
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, ImageSize, cols)
+
+	// Channels are planar: all the red values come first, then green, then blue.
+	assert.Equal(t, 1.0, x.At(0, 0))
+	assert.Equal(t, 1.0, x.At(0, Height*Width-1))
+	assert.Equal(t, 0.0, x.At(0, Height*Width))
+	assert.InDelta(t, 0.2, x.At(0, 2*Height*Width), 1e-12)
+	assert.InDelta(t, 0.4, x.At(1, Height*Width+5), 1e-12)
+
+	rows, cols = y.Dims()
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, Classes, cols)
+	assert.Equal(t, 1.0, y.At(0, 3))
+	assert.Equal(t, 1.0, y.At(1, 9))
+	assert.Equal(t, 2.0, sum(y.RawMatrix().Data))
+}
+
+func TestLoad100(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(record([]byte{19, 99}, 0, 0, 0))
+	buf.Write(record([]byte{4, 0}, 0, 0, 0))
+
+	x, coarse, fine, err := Load100(&buf)
+	require.NoError(t, err)
+
+	rows, _ := x.Dims()
+	assert.Equal(t, 2, rows)
+
+	_, cols := coarse.Dims()
+	assert.Equal(t, CoarseClasses, cols)
+	assert.Equal(t, 1.0, coarse.At(0, 19))
+	assert.Equal(t, 1.0, coarse.At(1, 4))
+
+	_, cols = fine.Dims()
+	assert.Equal(t, FineClasses, cols)
+	assert.Equal(t, 1.0, fine.At(0, 99))
+	assert.Equal(t, 1.0, fine.At(1, 0))
+}
+
+func TestLoadRejectsInvalidData(t *testing.T) {
+	_, _, err := Load10(bytes.NewReader(record([]byte{10}, 0, 0, 0)))
+	assert.Error(t, err)
+
+	_, _, _, err = Load100(bytes.NewReader(record([]byte{20, 0}, 0, 0, 0)))
+	assert.Error(t, err)
+
+	truncated := record([]byte{1}, 0, 0, 0)
+	_, _, err = Load10(bytes.NewReader(truncated[:len(truncated)-1]))
+	assert.Error(t, err)
+
+	_, _, err = Load10(bytes.NewReader(nil))
+	assert.Error(t, err)
+}
+
+func TestLoad10Files(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cifar")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	first := filepath.Join(dir, "data_batch_1.bin")
+	second := filepath.Join(dir, "data_batch_2.bin")
+	require.NoError(t, ioutil.WriteFile(first, append(record([]byte{0}, 0, 0, 0), record([]byte{1}, 0, 0, 0)...), 0644))
+	require.NoError(t, ioutil.WriteFile(second, record([]byte{2}, 255, 255, 255), 0644))
+
+	x, y, err := Load10Files(first, second)
+	require.NoError(t, err)
+
+	rows, _ := x.Dims()
+	assert.Equal(t, 3, rows)
+	assert.Equal(t, 1.0, x.At(2, 0))
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 1.0, y.At(i, i))
+	}
+
+	_, _, err = Load10Files(filepath.Join(dir, "missing.bin"))
+	assert.Error(t, err)
+}
+
+func sum(vs []float64) float64 {
+	total := 0.0
+	for _, v := range vs {
+		total += v
+	}
+	return total
+}
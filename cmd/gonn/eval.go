@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rosshemsley/gonn/metrics"
+	"github.com/rosshemsley/gonn/mnist"
+	"github.com/rosshemsley/gonn/nn"
+	"gonum.org/v1/gonum/mat"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	evalCommand    = kingpin.Command("eval", "Evaluate a saved model on gzipped MNIST style images and labels.")
+	evalModelPath  = evalCommand.Flag("model", "Path to a model saved with SaveFile.").Required().ExistingFile()
+	evalImagesPath = evalCommand.Flag("images", "Path to gzipped MNIST images.").Required().ExistingFile()
+	evalLabelsPath = evalCommand.Flag("labels", "Path to gzipped MNIST labels.").Required().ExistingFile()
+)
+
+func evaluate() {
+	model, err := nn.LoadModelFile(*evalModelPath)
+	if err != nil {
+		log.Fatalf("Failed to load model: %s", err)
+	}
+
+	x, err := mnist.LoadImagesGzipFile(*evalImagesPath)
+	if err != nil {
+		log.Fatalf("Failed to load images: %s", err)
+	}
+
+	// The labels are one-hot encoded with a column for each output of the model.
+	yHat := model.Predict(x)
+	_, classes := yHat.Dims()
+	y, err := mnist.LoadLabelsGzipFileWithClasses(*evalLabelsPath, classes)
+	if err != nil {
+		log.Fatalf("Failed to load labels: %s", err)
+	}
+
+	rows, _ := x.Dims()
+	if labels, _ := y.Dims(); labels != rows {
+		log.Fatalf("Found %d images but %d labels", rows, labels)
+	}
+
+	fmt.Printf("Accuracy: %.2f%% (%d images)\n\n", metrics.Accuracy(y, yHat)*100, rows)
+	printClassScores(metrics.PerClass(y, yHat))
+	fmt.Println()
+	printConfusionMatrix(metrics.ConfusionMatrix(y, yHat))
+}
+
+func printClassScores(scores []metrics.ClassScores) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "class\tprecision\trecall\tf1\tsupport\t")
+	for c, s := range scores {
+		fmt.Fprintf(w, "%d\t%.4f\t%.4f\t%.4f\t%d\t\n", c, s.Precision, s.Recall, s.F1, s.Support)
+	}
+	w.Flush()
+}
+
+// printConfusionMatrix prints a row for each target class, and a column for each predicted class.
+func printConfusionMatrix(confusion *mat.Dense) {
+	n, _ := confusion.Dims()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
+
+	fmt.Fprint(w, "actual\\predicted\t")
+	for j := 0; j < n; j++ {
+		fmt.Fprintf(w, "%d\t", j)
+	}
+	fmt.Fprintln(w)
+
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(w, "%d\t", i)
+		for j := 0; j < n; j++ {
+			fmt.Fprintf(w, "%d\t", int(confusion.At(i, j)))
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
@@ -5,20 +5,37 @@ import (
 	"os"
 
 	"github.com/rosshemsley/gonn/examples/mnist"
+	"github.com/rosshemsley/gonn/examples/regression"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
 var (
-	exampleName = kingpin.Arg("example", "Name of example to run.").Required().String()
+	runCommand  = kingpin.Command("run", "Run an example.").Default()
+	exampleName = runCommand.Arg("example", "Name of example to run.").Required().String()
+	resume      = runCommand.Flag("resume", "Resume training from the example's last checkpoint, if it saves them.").Bool()
 
 	examples = map[string]func(){
-		"mnist": mnist.Run,
+		"mnist":      func() { mnist.Run(*resume) },
+		"regression": regression.Run,
 	}
 )
 
 func main() {
-	kingpin.Parse()
+	switch kingpin.Parse() {
+	case runCommand.FullCommand():
+		runExample()
+	case trainCommand.FullCommand():
+		train()
+	case serveCommand.FullCommand():
+		serveModel()
+	case evalCommand.FullCommand():
+		evaluate()
+	case predictCommand.FullCommand():
+		predict()
+	}
+}
 
+func runExample() {
 	run, ok := examples[*exampleName]
 	if !ok {
 		fmt.Fprintf(os.Stderr, "Example '%s' not found, available examples are:\n", *exampleName)
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/rosshemsley/gonn/mnist"
+	"github.com/rosshemsley/gonn/nn"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	predictCommand    = kingpin.Command("predict", "Classify PNG images of digits with a saved model.")
+	predictModelPath  = predictCommand.Flag("model", "Path to a model saved with SaveFile.").Required().ExistingFile()
+	predictTop        = predictCommand.Flag("top", "Number of most likely classes to print.").Short('k').Default("3").Int()
+	predictImagePaths = predictCommand.Arg("images", "PNG images to classify.").Required().ExistingFiles()
+)
+
+func predict() {
+	if *predictTop < 1 {
+		kingpin.Fatalf("--top must be at least 1, got %d", *predictTop)
+	}
+
+	model, err := nn.LoadModelFile(*predictModelPath)
+	if err != nil {
+		log.Fatalf("Failed to load model: %s", err)
+	}
+
+	for _, path := range *predictImagePaths {
+		x, err := mnist.LoadPNGFile(path)
+		if err != nil {
+			log.Fatalf("Failed to load %s: %s", path, err)
+		}
+
+		probabilities := model.PredictProbabilities(x).RawRowView(0)
+		classes := make([]int, len(probabilities))
+		for i := range classes {
+			classes[i] = i
+		}
+		sort.SliceStable(classes, func(a, b int) bool {
+			return probabilities[classes[a]] > probabilities[classes[b]]
+		})
+
+		if *predictTop < len(classes) {
+			classes = classes[:*predictTop]
+		}
+
+		fmt.Println(path)
+		for _, c := range classes {
+			fmt.Printf("  %d: %.4f\n", c, probabilities[c])
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rosshemsley/gonn/nn"
+	"github.com/rosshemsley/gonn/serve"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	serveCommand      = kingpin.Command("serve", "Serve predictions from a saved model over HTTP.")
+	serveModelPath    = serveCommand.Arg("model", "Path to a model saved with SaveFile.").Required().String()
+	serveAddr         = serveCommand.Flag("addr", "Address to listen on.").Default(":8080").String()
+	serveMaxBatchSize = serveCommand.Flag("max-batch-size", "Number of rows at which a batch is run without waiting for more requests.").Default("64").Int()
+	serveBatchTimeout = serveCommand.Flag("batch-timeout", "How long to wait for more requests to add to a batch.").Default("5ms").Duration()
+	serveGracePeriod  = serveCommand.Flag("shutdown-timeout", "How long to wait for requests to finish when shutting down.").Default("10s").Duration()
+)
+
+func serveModel() {
+	model, err := nn.LoadModelFile(*serveModelPath)
+	if err != nil {
+		log.Fatalf("Failed to load model: %s", err)
+	}
+
+	handler := serve.NewServer(model,
+		serve.WithName(*serveModelPath),
+		serve.WithMaxBatchSize(*serveMaxBatchSize),
+		serve.WithBatchTimeout(*serveBatchTimeout),
+	)
+	srv := &http.Server{Addr: *serveAddr, Handler: handler}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("Serving %s on %s", *serveModelPath, *serveAddr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-errs:
+		log.Fatalf("Server failed: %s", err)
+	case sig := <-signals:
+		log.Printf("Received %s, shutting down", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *serveGracePeriod)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Failed to shut down cleanly: %s", err)
+	}
+	handler.Close()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log"
+
+	"github.com/rosshemsley/gonn/experiment"
+	"github.com/rosshemsley/gonn/sgd"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	trainCommand    = kingpin.Command("train", "Train a network described by a config file.")
+	trainConfigPath = trainCommand.Flag("config", "Path to a YAML or JSON experiment config.").Short('c').Required().ExistingFile()
+)
+
+func train() {
+	cfg, err := experiment.LoadFile(*trainConfigPath)
+	if err != nil {
+		log.Fatalf("Invalid config %s: %s", *trainConfigPath, err)
+	}
+
+	logProgress := sgd.Callbacks{
+		OnEpochEnd: func(e sgd.Epoch) bool {
+			log.Printf("Epoch %d: train loss %f, validation loss %f %v", e.Epoch, e.TrainLoss, e.ValidationLoss, e.Metrics)
+			return false
+		},
+	}
+
+	history, err := experiment.Run(cfg, sgd.WithCallbacks(logProgress))
+	if err != nil {
+		log.Fatalf("Training failed: %s", err)
+	}
+
+	if best, ok := history.Best(); ok {
+		log.Printf("Best validation loss: %f (epoch %d)", best.ValidationLoss, best.Epoch)
+	}
+	log.Printf("Saved model to %s", cfg.Output)
+}
@@ -2,14 +2,17 @@ package mnist
 
 import (
 	"log"
+	"runtime"
 
+	"github.com/rosshemsley/gonn/metrics"
 	"github.com/rosshemsley/gonn/mnist"
 	"github.com/rosshemsley/gonn/nn"
 	"github.com/rosshemsley/gonn/sgd"
-	"gonum.org/v1/gonum/mat"
 )
 
-func Run() {
+// Run trains a network on MNIST, saving a checkpoint every 5 epochs.
+// If resume is set, training continues from the last checkpoint if there is one.
+func Run(resume bool) {
 	x, err := mnist.LoadImagesGzipFile("data/train-images-idx3-ubyte.gz")
 	if err != nil {
 		log.Fatalf("Failed to load images: %s", err)
@@ -25,17 +28,47 @@ func Run() {
 		nn.NewFullyConnectedLayer(xCols, 50),
 		nn.NewFullyConnectedLayer(50, 30),
 		nn.NewDropoutLayer(0.1),
-		nn.NewFullyConnectedLayer(30, 10),
+		nn.NewDenseLayer(30, 10),
 		nn.NewSoftMaxLayer(),
 	)
 
 	log.Printf("Classification rate: %.2f%%", evaluate(dnn))
 	startRate := evaluate(dnn)
 
-	sgd.SGD(x, y, nn.L2Loss, dnn, sgd.WithBatchSize(64), sgd.WithEpochs(150))
+	const epochs = 150
+	logProgress := sgd.Callbacks{
+		OnEpochEnd: func(e sgd.Epoch) bool {
+			log.Printf("Validation set loss: %f, accuracy: %.2f%% (epoch %d/%d)", e.ValidationLoss, e.Metrics["accuracy"]*100, e.Epoch, epochs)
+			return false
+		},
+	}
+
+	settings := []sgd.Setting{
+		sgd.WithBatchSize(64),
+		sgd.WithWorkers(runtime.NumCPU()),
+		sgd.WithShuffledSplit(),
+		sgd.WithStratifiedSplit(),
+		sgd.WithEpochs(epochs),
+		sgd.WithEarlyStopping(10, 1e-4),
+		sgd.WithCheckpoint("mnist.checkpoint", 5),
+		sgd.WithMetric("accuracy", metrics.Accuracy),
+		sgd.WithCallbacks(logProgress),
+	}
+	if resume {
+		settings = append(settings, sgd.WithResume("mnist.checkpoint"))
+	}
+
+	_, err = sgd.SGD(x, y, nn.CrossEntropyLoss, dnn, settings...)
+	if err != nil {
+		log.Fatalf("Training failed: %s", err)
+	}
 
 	endRate := evaluate(dnn)
 	log.Printf("Classification rate on test set: from %.2f%% to %.2f%%", startRate, endRate)
+
+	if err := dnn.SaveFile("mnist.gonn"); err != nil {
+		log.Fatalf("Failed to save model: %s", err)
+	}
 }
 
 func evaluate(dnn nn.Value) float64 {
@@ -49,25 +82,6 @@ func evaluate(dnn nn.Value) float64 {
 		log.Fatalf("Failed to load labels: %s", err)
 	}
 
-	rows, _ := x.Dims()
-
-	correct := 0
-	for i := 0; i < rows; i++ {
-		img := extract(i, x)
-		yHat := dnn.Forwards(img)
-
-		classified := mnist.LabelValue(yHat.RawRowView(0))
-		expected := mnist.LabelValue(extract(i, y).RawRowView(0))
-		if classified == expected {
-			correct++
-		}
-	}
-
-	return float64(correct) / float64(rows) * 100.0
-}
-
-func extract(i int, x *mat.Dense) *mat.Dense {
-	_, cols := x.Dims()
-	vs := x.RawRowView(i)
-	return mat.NewDense(1, cols, vs)
+	dnn.SetTrainingEnabled(false)
+	return metrics.Accuracy(y, dnn.Forwards(x)) * 100.0
 }
@@ -0,0 +1,77 @@
+package regression
+
+import (
+	"log"
+	"math"
+	"math/rand"
+
+	"github.com/rosshemsley/gonn/metrics"
+	"github.com/rosshemsley/gonn/nn"
+	"github.com/rosshemsley/gonn/sgd"
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	features = 3
+	seed     = 1
+)
+
+// Run fits a small network to a synthetic, noisy non-linear function of three features.
+func Run() {
+	rng := rand.New(rand.NewSource(seed))
+	x, y := dataset(rng, 2000)
+	xTest, yTest := dataset(rng, 500)
+
+	dnn := nn.NewFeedForwardNetwork(
+		nn.NewFullyConnectedLayer(features, 32, nn.WithRand(rng)),
+		nn.NewFullyConnectedLayer(32, 16, nn.WithRand(rng)),
+		nn.NewDenseLayer(16, 1, nn.WithRand(rng)),
+	)
+
+	const epochs = 100
+	logProgress := sgd.Callbacks{
+		OnEpochEnd: func(e sgd.Epoch) bool {
+			if e.Epoch%10 == 0 {
+				log.Printf("Validation set RMSE: %f, R²: %f (epoch %d/%d)", e.Metrics["rmse"], e.Metrics["r2"], e.Epoch, epochs)
+			}
+			return false
+		},
+	}
+
+	_, err := sgd.SGD(
+		x, y, nn.L2Loss, dnn,
+		sgd.WithBatchSize(32),
+		sgd.WithEpochs(epochs),
+		sgd.WithSeed(seed),
+		sgd.WithOptimizer(nn.NewAdam(0.01, 0.9, 0.999)),
+		sgd.WithEarlyStopping(10, 1e-5),
+		sgd.WithMetric("rmse", metrics.RMSE),
+		sgd.WithMetric("r2", metrics.R2),
+		sgd.WithCallbacks(logProgress),
+	)
+	if err != nil {
+		log.Fatalf("Training failed: %s", err)
+	}
+
+	dnn.SetTrainingEnabled(false)
+	yHat := dnn.Forwards(xTest)
+	log.Printf("Test set MSE: %f", metrics.MSE(yTest, yHat))
+	log.Printf("Test set RMSE: %f", metrics.RMSE(yTest, yHat))
+	log.Printf("Test set MAE: %f", metrics.MAE(yTest, yHat))
+	log.Printf("Test set R²: %f", metrics.R2(yTest, yHat))
+	log.Printf("Test set explained variance: %f", metrics.ExplainedVariance(yTest, yHat))
+}
+
+// dataset samples rows of y = sin(πx₀) + x₁² - 0.5x₂ + ε, with each x uniform in [-1, 1)
+// and ε ~ N(0, 0.1²).
+func dataset(rng *rand.Rand, rows int) (x, y *mat.Dense) {
+	x = nn.NewRandomMatrixFrom(rng, rows, features)
+	y = mat.NewDense(rows, 1, nil)
+
+	for i := 0; i < rows; i++ {
+		v := x.RawRowView(i)
+		y.Set(i, 0, math.Sin(math.Pi*v[0])+v[1]*v[1]-0.5*v[2]+0.1*rng.NormFloat64())
+	}
+
+	return x, y
+}
@@ -0,0 +1,380 @@
+package experiment
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/rosshemsley/gonn/metrics"
+	"github.com/rosshemsley/gonn/nn"
+	"github.com/rosshemsley/gonn/sgd"
+	"gonum.org/v1/gonum/mat"
+)
+
+var metricsByName = map[string]sgd.Metric{
+	"accuracy":           metrics.Accuracy,
+	"top5_accuracy":      metrics.TopKAccuracy(5),
+	"precision":          metrics.Precision(metrics.Macro),
+	"recall":             metrics.Recall(metrics.Macro),
+	"f1":                 metrics.F1(metrics.Macro),
+	"roc_auc":            metrics.ROCAUC,
+	"log_loss":           metrics.LogLoss,
+	"mse":                metrics.MSE,
+	"rmse":               metrics.RMSE,
+	"mae":                metrics.MAE,
+	"r2":                 metrics.R2,
+	"explained_variance": metrics.ExplainedVariance,
+}
+
+func (c *Config) loss() (nn.Loss, error) {
+	switch c.Loss {
+	case "l2", "mse":
+		return nn.L2Loss, nil
+	case "cross_entropy":
+		return nn.CrossEntropyLoss, nil
+	case "softmax_cross_entropy":
+		return nn.SoftmaxCrossEntropyLoss, nil
+	case "":
+		return nil, fmt.Errorf("loss: a loss is required")
+	default:
+		return nil, fmt.Errorf("loss: unknown loss: %s", c.Loss)
+	}
+}
+
+func (c *Config) optimizer() (nn.Optimizer, error) {
+	o := c.Optimizer
+	lr := orDefault(o.LearningRate, nn.LearningRate)
+
+	switch o.Type {
+	case "sgd", "":
+		return nn.NewSGD(lr), nil
+	case "momentum":
+		return nn.NewMomentum(lr, orDefault(o.Momentum, 0.9)), nil
+	case "nesterov":
+		return nn.NewNesterov(lr, orDefault(o.Momentum, 0.9)), nil
+	case "rmsprop":
+		return nn.NewRMSProp(lr, orDefault(o.Decay, 0.9)), nil
+	case "adagrad":
+		return nn.NewAdagrad(lr), nil
+	case "adam":
+		return nn.NewAdam(lr, orDefault(o.Beta1, 0.9), orDefault(o.Beta2, 0.999)), nil
+	case "adamw":
+		return nn.NewAdamW(lr, orDefault(o.Beta1, 0.9), orDefault(o.Beta2, 0.999), orDefault(o.WeightDecay, 0.01)), nil
+	default:
+		return nil, fmt.Errorf("optimizer: unknown type: %s", o.Type)
+	}
+}
+
+func (c *Config) schedule() (sgd.Schedule, error) {
+	s := c.Schedule
+	if s == nil {
+		return nil, nil
+	}
+
+	var result sgd.Schedule
+	switch s.Type {
+	case "step":
+		if s.Step < 1 {
+			return nil, fmt.Errorf("schedule: step must be at least 1")
+		}
+		result = sgd.NewStepDecay(s.Step, orDefault(s.Gamma, 0.1))
+	case "exponential":
+		result = sgd.NewExponentialDecay(orDefault(s.Gamma, 0.95))
+	case "cosine":
+		if s.Period < 1 {
+			return nil, fmt.Errorf("schedule: period must be at least 1")
+		}
+		result = sgd.NewCosineAnnealing(s.Period, s.PeriodMultiplier, s.MinLearningRate)
+	case "plateau":
+		result = sgd.NewReduceOnPlateau(orDefault(s.Factor, 0.1), s.Patience, s.MinLearningRate)
+	case "":
+		if s.WarmupEpochs == 0 {
+			return nil, fmt.Errorf("schedule: a type or warmup_epochs is required")
+		}
+	default:
+		return nil, fmt.Errorf("schedule: unknown type: %s", s.Type)
+	}
+
+	if s.WarmupEpochs > 0 {
+		result = sgd.NewLinearWarmup(s.WarmupEpochs, result)
+	}
+	return result, nil
+}
+
+// shape tracks the size of the output of each layer while building a network.
+// image is nil once the output is no longer laid out as an image.
+type shape struct {
+	size  int
+	image *nn.ImageShape
+}
+
+// Network builds the layers of the config for inputs with the given number of columns.
+// Weights are initialized from rng, if it is not nil.
+func (c *Config) Network(inputs int, rng *rand.Rand) (*nn.FeedForwardNetwork, error) {
+	s := shape{size: inputs}
+	if image := c.imageShape(); image != nil {
+		if image.Size() != inputs {
+			return nil, fmt.Errorf("data: shape %v does not match inputs of size %d", c.Data.Shape, inputs)
+		}
+		s.image = image
+	}
+
+	var settings []nn.LayerSetting
+	if rng != nil {
+		settings = append(settings, nn.WithRand(rng))
+	}
+
+	layers := make([]nn.Value, len(c.Model))
+	for i, l := range c.Model {
+		layer, next, err := buildLayer(l, s, settings)
+		if err != nil {
+			return nil, fmt.Errorf("model: layer %d (%s): %s", i+1, l.Type, err)
+		}
+		layers[i], s = layer, next
+	}
+
+	return nn.NewFeedForwardNetwork(layers...), nil
+}
+
+func (c *Config) imageShape() *nn.ImageShape {
+	switch {
+	case len(c.Data.Shape) == 3:
+		return &nn.ImageShape{Channels: c.Data.Shape[0], Height: c.Data.Shape[1], Width: c.Data.Shape[2]}
+	case c.Data.Format == "mnist":
+		return &nn.ImageShape{Channels: 1, Height: 28, Width: 28}
+	default:
+		return nil
+	}
+}
+
+// validate checks the fields of a layer that do not depend on the size of its input.
+func (l Layer) validate() error {
+	if l.Stride < 0 || l.Padding < 0 || l.Dilation < 0 {
+		return fmt.Errorf("stride, padding and dilation must not be negative")
+	}
+	if l.Dilation != 0 && l.Type != "conv2d" {
+		return fmt.Errorf("only conv2d layers take a dilation")
+	}
+
+	switch l.Type {
+	case "dense":
+		if l.Units < 1 {
+			return fmt.Errorf("units must be at least 1")
+		}
+		if l.Activation != "" && l.Activation != "linear" {
+			if _, err := activation(l.Activation, l.Alpha, l.Units); err != nil {
+				return err
+			}
+		}
+	case "dropout":
+		if l.Rate < 0 || l.Rate >= 1 {
+			return fmt.Errorf("rate must be in [0, 1)")
+		}
+	case "conv2d":
+		if l.Filters < 1 || l.KernelSize < 1 {
+			return fmt.Errorf("filters and kernel_size must be at least 1")
+		}
+	case "max_pool2d", "avg_pool2d":
+		if l.Size < 1 {
+			return fmt.Errorf("size must be at least 1")
+		}
+		if l.Padding >= l.Size {
+			return fmt.Errorf("padding must be less than size")
+		}
+	case "global_average_pool", "batch_norm", "layer_norm":
+	default:
+		if _, err := activation(l.Type, l.Alpha, 1); err != nil {
+			return fmt.Errorf("unknown layer type")
+		}
+	}
+	return nil
+}
+
+func buildLayer(l Layer, s shape, settings []nn.LayerSetting) (nn.Value, shape, error) {
+	if err := l.validate(); err != nil {
+		return nil, s, err
+	}
+
+	switch l.Type {
+	case "dense":
+		if l.Activation != "" && l.Activation != "linear" {
+			activation, err := activation(l.Activation, l.Alpha, l.Units)
+			if err != nil {
+				return nil, s, err
+			}
+			settings = append(settings, nn.WithActivation(activation))
+		}
+		return nn.NewDenseLayer(s.size, l.Units, settings...), shape{size: l.Units}, nil
+
+	case "dropout":
+		return nn.NewDropoutLayer(l.Rate, settings...), s, nil
+
+	case "conv2d":
+		if s.image == nil {
+			return nil, s, fmt.Errorf("input is not an image, give the data a shape")
+		}
+		layer, err := safely(func() nn.Value {
+			return nn.NewConv2DLayer(*s.image, l.Filters, l.KernelSize, append(settings, windowSettings(l)...)...)
+		})
+		if err != nil {
+			return nil, s, err
+		}
+		out := layer.(*nn.Conv2D).OutputShape()
+		return layer, shape{size: out.Size(), image: &out}, nil
+
+	case "max_pool2d", "avg_pool2d":
+		if s.image == nil {
+			return nil, s, fmt.Errorf("input is not an image, give the data a shape")
+		}
+		layer, err := safely(func() nn.Value {
+			if l.Type == "max_pool2d" {
+				return nn.NewMaxPool2DLayer(*s.image, l.Size, windowSettings(l)...)
+			}
+			return nn.NewAvgPool2DLayer(*s.image, l.Size, windowSettings(l)...)
+		})
+		if err != nil {
+			return nil, s, err
+		}
+		out := layer.(interface{ OutputShape() nn.ImageShape }).OutputShape()
+		return layer, shape{size: out.Size(), image: &out}, nil
+
+	case "global_average_pool":
+		if s.image == nil {
+			return nil, s, fmt.Errorf("input is not an image, give the data a shape")
+		}
+		return nn.NewGlobalAveragePoolLayer(*s.image), shape{size: s.image.Channels}, nil
+
+	// The remaining layers do not change the size or layout of their input.
+	case "batch_norm":
+		return nn.NewBatchNormLayer(s.size), s, nil
+
+	case "layer_norm":
+		return nn.NewLayerNormLayer(s.size), s, nil
+
+	default:
+		a, err := activation(l.Type, l.Alpha, s.size)
+		return a, s, err
+	}
+}
+
+// windowSettings returns the settings of a convolution or pooling layer that were given.
+// Negative values, and dilations of pooling layers, are rejected by validate.
+func windowSettings(l Layer) []nn.LayerSetting {
+	var settings []nn.LayerSetting
+	if l.Stride > 0 {
+		settings = append(settings, nn.WithStride(l.Stride))
+	}
+	if l.Padding > 0 {
+		settings = append(settings, nn.WithPadding(l.Padding))
+	}
+	if l.Dilation > 0 {
+		settings = append(settings, nn.WithDilation(l.Dilation))
+	}
+	return settings
+}
+
+func activation(name string, alpha float64, size int) (nn.Value, error) {
+	switch name {
+	case "relu":
+		return nn.NewRelu(), nil
+	case "sigmoid":
+		return nn.NewSigmoid(), nil
+	case "tanh":
+		return nn.NewTanh(), nil
+	case "leaky_relu":
+		return nn.NewLeakyReLU(orDefault(alpha, 0.01)), nil
+	case "elu":
+		return nn.NewELU(orDefault(alpha, 1)), nil
+	case "selu":
+		return nn.NewSELU(), nil
+	case "gelu":
+		return nn.NewGELU(), nil
+	case "swish":
+		return nn.NewSwish(), nil
+	case "softplus":
+		return nn.NewSoftplus(), nil
+	case "softmax":
+		return nn.NewSoftMaxLayer(), nil
+	case "prelu":
+		return nn.NewPReLU(size), nil
+	default:
+		return nil, fmt.Errorf("unknown activation: %s", name)
+	}
+}
+
+// settings returns the settings for sgd.SGD given by the config.
+func (c *Config) settings(xVal, yVal *mat.Dense) ([]sgd.Setting, error) {
+	t := c.Training
+	optimizer, err := c.optimizer()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := []sgd.Setting{sgd.WithOptimizer(optimizer)}
+	if t.BatchSize > 0 {
+		settings = append(settings, sgd.WithBatchSize(t.BatchSize))
+	}
+	if t.Epochs > 0 {
+		settings = append(settings, sgd.WithEpochs(t.Epochs))
+	}
+	if t.ValidationPercent > 0 {
+		settings = append(settings, sgd.WithValidationSetSize(t.ValidationPercent))
+	}
+	if t.Regularization != nil {
+		settings = append(settings, sgd.WithRegularizationConstant(*t.Regularization))
+	}
+	if t.Seed != nil {
+		settings = append(settings, sgd.WithSeed(*t.Seed))
+	}
+	if t.Workers > 0 {
+		settings = append(settings, sgd.WithWorkers(t.Workers))
+	}
+	if t.Shuffle {
+		settings = append(settings, sgd.WithShuffledSplit())
+	}
+	if t.Stratify {
+		settings = append(settings, sgd.WithStratifiedSplit())
+	}
+	if t.EarlyStopping != nil {
+		settings = append(settings, sgd.WithEarlyStopping(t.EarlyStopping.Patience, t.EarlyStopping.MinDelta))
+	}
+	if cp := t.Checkpoint; cp != nil && cp.Path != "" {
+		settings = append(settings, sgd.WithCheckpoint(cp.Path, cp.Interval))
+		if cp.Resume {
+			settings = append(settings, sgd.WithResume(cp.Path))
+		}
+	}
+
+	schedule, err := c.schedule()
+	if err != nil {
+		return nil, err
+	}
+	if schedule != nil {
+		settings = append(settings, sgd.WithSchedule(schedule))
+	}
+
+	for _, name := range c.Metrics {
+		settings = append(settings, sgd.WithMetric(name, metricsByName[name]))
+	}
+
+	if xVal != nil {
+		settings = append(settings, sgd.WithValidationData(xVal, yVal))
+	}
+	return settings, nil
+}
+
+// safely converts a panic from a layer constructor, such as a kernel that does not fit, into an error.
+func safely(build func() nn.Value) (v nn.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return build(), nil
+}
+
+func orDefault(v, def float64) float64 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
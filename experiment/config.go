@@ -0,0 +1,244 @@
+// Package experiment trains networks described by a declarative config file,
+// so that experiments can be run with `gonn train` without writing any Go.
+//
+// A config is written in YAML (or JSON, which is also valid YAML), for example:
+//
+//	data:
+//	  format: mnist
+//	  train: {inputs: data/train-images-idx3-ubyte.gz, targets: data/train-labels-idx1-ubyte.gz}
+//	model:
+//	  - {type: dense, units: 50, activation: relu}
+//	  - {type: dropout, rate: 0.1}
+//	  - {type: dense, units: 10}
+//	  - {type: softmax}
+//	loss: cross_entropy
+//	optimizer: {type: adam, learning_rate: 0.001}
+//	training: {batch_size: 64, epochs: 10}
+//	metrics: [accuracy]
+//	output: mnist.gonn
+//
+// The input size of each layer is worked out from the data and the layers before it.
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Config describes an experiment: the data, the network and how to train it.
+type Config struct {
+	Data      Data      `yaml:"data"`
+	Model     []Layer   `yaml:"model"`
+	Loss      string    `yaml:"loss"`
+	Optimizer Optimizer `yaml:"optimizer"`
+	Schedule  *Schedule `yaml:"schedule"`
+	Training  Training  `yaml:"training"`
+
+	// Metrics are reported on the validation set every epoch: accuracy, top5_accuracy,
+	// precision, recall, f1 (macro averaged), roc_auc, log_loss, mse, rmse, mae, r2
+	// or explained_variance.
+	Metrics []string `yaml:"metrics"`
+
+	// Output is the path the trained network is saved to.
+	Output string `yaml:"output"`
+}
+
+// Data says where to load the training data from, and in which format.
+type Data struct {
+	// Format is "mnist" for gzipped image and label files in the IDX layout of MNIST
+	// (which Fashion-MNIST, KMNIST and EMNIST share), or "csv" for a CSV file with the
+	// targets in the last columns.
+	Format string `yaml:"format"`
+
+	Train Files `yaml:"train"`
+
+	// Validation is optional. When it is given, it is used for validation
+	// instead of holding out part of the training data.
+	Validation *Files `yaml:"validation"`
+
+	// Classes is the number of classes of the labels of the mnist format. Defaults to 10.
+	Classes int `yaml:"classes"`
+
+	// TargetColumns is the number of columns at the end of each row of a CSV file
+	// that hold the targets. Defaults to 1.
+	TargetColumns int `yaml:"target_columns"`
+
+	// Header says whether the first row of a CSV file holds column names.
+	Header bool `yaml:"header"`
+
+	// Shape is the [channels, height, width] of each input, for convolution and pooling layers.
+	// Defaults to [1, 28, 28] for MNIST.
+	Shape []int `yaml:"shape"`
+}
+
+// Files are the paths of a dataset. CSV files hold the inputs and targets together in Inputs.
+type Files struct {
+	Inputs  string `yaml:"inputs"`
+	Targets string `yaml:"targets"`
+}
+
+// Layer describes a single layer. Which fields apply depends on Type:
+//
+//	dense:               units, activation (linear by default), alpha for leaky_relu or elu
+//	dropout:             rate
+//	conv2d:              filters, kernel_size, stride, padding, dilation
+//	max_pool2d:          size, stride, padding
+//	avg_pool2d:          size, stride, padding
+//	global_average_pool, batch_norm, layer_norm, prelu
+//	relu, sigmoid, tanh, leaky_relu, elu, selu, gelu, swish, softplus, softmax
+type Layer struct {
+	Type       string  `yaml:"type"`
+	Units      int     `yaml:"units"`
+	Activation string  `yaml:"activation"`
+	Alpha      float64 `yaml:"alpha"`
+	Rate       float64 `yaml:"rate"`
+	Filters    int     `yaml:"filters"`
+	KernelSize int     `yaml:"kernel_size"`
+	Size       int     `yaml:"size"`
+	Stride     int     `yaml:"stride"`
+	Padding    int     `yaml:"padding"`
+	Dilation   int     `yaml:"dilation"`
+}
+
+// Optimizer describes how parameters are updated. Type is one of sgd, momentum, nesterov,
+// rmsprop, adagrad, adam or adamw. Fields that are not given take their usual defaults.
+type Optimizer struct {
+	Type         string  `yaml:"type"`
+	LearningRate float64 `yaml:"learning_rate"`
+	Momentum     float64 `yaml:"momentum"`
+	Decay        float64 `yaml:"decay"`
+	Beta1        float64 `yaml:"beta1"`
+	Beta2        float64 `yaml:"beta2"`
+	WeightDecay  float64 `yaml:"weight_decay"`
+}
+
+// Schedule describes how the learning rate changes over epochs. Type is one of step,
+// exponential, cosine or plateau, and WarmupEpochs optionally precedes it with a linear warmup.
+type Schedule struct {
+	Type             string  `yaml:"type"`
+	Step             int     `yaml:"step"`
+	Gamma            float64 `yaml:"gamma"`
+	Period           int     `yaml:"period"`
+	PeriodMultiplier int     `yaml:"period_multiplier"`
+	Factor           float64 `yaml:"factor"`
+	Patience         int     `yaml:"patience"`
+	MinLearningRate  float64 `yaml:"min_learning_rate"`
+	WarmupEpochs     int     `yaml:"warmup_epochs"`
+}
+
+// Training holds the settings passed to sgd.SGD. Fields that are not given take the defaults of sgd.
+type Training struct {
+	BatchSize         int      `yaml:"batch_size"`
+	Epochs            int      `yaml:"epochs"`
+	ValidationPercent int      `yaml:"validation_percent"`
+	Regularization    *float64 `yaml:"regularization"`
+	Seed              *int64   `yaml:"seed"`
+	Workers           int      `yaml:"workers"`
+	Shuffle           bool     `yaml:"shuffle"`
+	Stratify          bool     `yaml:"stratify"`
+
+	EarlyStopping *EarlyStopping `yaml:"early_stopping"`
+	Checkpoint    *Checkpoint    `yaml:"checkpoint"`
+}
+
+type EarlyStopping struct {
+	Patience int     `yaml:"patience"`
+	MinDelta float64 `yaml:"min_delta"`
+}
+
+// Checkpoint saves a checkpoint to Path every Interval epochs. If Resume is set,
+// training resumes from the checkpoint when it exists.
+type Checkpoint struct {
+	Path     string `yaml:"path"`
+	Interval int    `yaml:"interval"`
+	Resume   bool   `yaml:"resume"`
+}
+
+// LoadFile reads the config at path.
+func LoadFile(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// Parse reads a config from YAML or JSON, and checks that it is complete and that
+// its layers are valid.
+// Unknown fields are an error, to catch typos.
+func Parse(b []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(b, &cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (c *Config) validate() error {
+	switch c.Data.Format {
+	case "mnist":
+		if c.Data.Train.Inputs == "" || c.Data.Train.Targets == "" {
+			return errors.New("data: mnist needs both inputs and targets")
+		}
+	case "csv":
+		if c.Data.Train.Inputs == "" {
+			return errors.New("data: csv needs inputs")
+		}
+	case "":
+		return errors.New("data: format is required")
+	default:
+		return fmt.Errorf("data: unknown format: %s", c.Data.Format)
+	}
+
+	if c.Data.Classes < 0 {
+		return errors.New("data: classes must not be negative")
+	}
+
+	if len(c.Data.Shape) != 0 && len(c.Data.Shape) != 3 {
+		return errors.New("data: shape must be [channels, height, width]")
+	}
+
+	if len(c.Model) == 0 {
+		return errors.New("model: at least one layer is required")
+	}
+	for i, l := range c.Model {
+		if err := l.validate(); err != nil {
+			return fmt.Errorf("model: layer %d (%s): %s", i+1, l.Type, err)
+		}
+	}
+
+	// When the shape of the inputs is known, the layers can be checked to fit together
+	// before the data is loaded.
+	if image := c.imageShape(); image != nil {
+		if _, err := c.Network(image.Size(), nil); err != nil {
+			return err
+		}
+	}
+
+	if _, err := c.loss(); err != nil {
+		return err
+	}
+	if _, err := c.optimizer(); err != nil {
+		return err
+	}
+	if _, err := c.schedule(); err != nil {
+		return err
+	}
+	for _, name := range c.Metrics {
+		if _, ok := metricsByName[name]; !ok {
+			return fmt.Errorf("metrics: unknown metric: %s", name)
+		}
+	}
+
+	if c.Output == "" {
+		return errors.New("output: a path to save the model to is required")
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package experiment
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rosshemsley/gonn/nn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+const mnistConfig = `
+data:
+  format: mnist
+  train: {inputs: train-images.gz, targets: train-labels.gz}
+model:
+  - {type: conv2d, filters: 4, kernel_size: 3, padding: 1}
+  - {type: relu}
+  - {type: max_pool2d, size: 2}
+  - {type: batch_norm}
+  - {type: global_average_pool}
+  - {type: dense, units: 8, activation: leaky_relu, alpha: 0.1}
+  - {type: dropout, rate: 0.2}
+  - {type: dense, units: 10}
+  - {type: softmax}
+loss: cross_entropy
+optimizer: {type: adam, learning_rate: 0.001}
+schedule: {type: cosine, period: 5, warmup_epochs: 2}
+training:
+  batch_size: 32
+  epochs: 3
+  seed: 7
+  early_stopping: {patience: 2}
+metrics: [accuracy, f1]
+output: model.gonn
+`
+
+func TestParseAndBuildNetwork(t *testing.T) {
+	cfg, err := Parse([]byte(mnistConfig))
+	require.NoError(t, err)
+
+	assert.Equal(t, 32, cfg.Training.BatchSize)
+	assert.Equal(t, int64(7), *cfg.Training.Seed)
+	assert.Equal(t, 0.1, cfg.Model[5].Alpha)
+
+	net, err := cfg.Network(28*28, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+
+	y := net.Forwards(mat.NewDense(2, 28*28, nil))
+	rows, cols := y.Dims()
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, 10, cols)
+
+	settings, err := cfg.settings(nil, nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, settings)
+}
+
+func TestParseAcceptsJSON(t *testing.T) {
+	cfg, err := Parse([]byte(`{
+		"data": {"format": "csv", "train": {"inputs": "data.csv"}},
+		"model": [{"type": "dense", "units": 1}],
+		"loss": "l2",
+		"output": "model.gonn"
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, "csv", cfg.Data.Format)
+}
+
+func TestParseRejectsInvalidConfigs(t *testing.T) {
+	valid := `
+data: {format: csv, train: {inputs: data.csv}}
+model: [{type: dense, units: 1}]
+loss: l2
+output: model.gonn
+`
+	_, err := Parse([]byte(valid))
+	require.NoError(t, err)
+
+	cases := map[string]string{
+		"unknown field":     valid + "epochs: 3\n",
+		"unknown format":    strings.Replace(valid, "format: csv", "format: parquet", 1),
+		"unknown loss":      strings.Replace(valid, "loss: l2", "loss: hinge", 1),
+		"missing output":    strings.Replace(valid, "output: model.gonn", "", 1),
+		"unknown optimizer": valid + "optimizer: {type: lbfgs}\n",
+		"unknown metric":    valid + "metrics: [bleu]\n",
+		"bad schedule":      valid + "schedule: {type: step}\n",
+		"unknown layer":     strings.Replace(valid, "type: dense", "type: densse", 1),
+		"no units":          strings.Replace(valid, "units: 1", "units: 0", 1),
+		"bad activation":    strings.Replace(valid, "units: 1", "units: 1, activation: cube", 1),
+		"negative stride":   strings.Replace(valid, "{type: dense, units: 1}", "{type: max_pool2d, size: 2, stride: -1}", 1),
+		"negative padding":  strings.Replace(valid, "{type: dense, units: 1}", "{type: conv2d, filters: 1, kernel_size: 3, padding: -1}", 1),
+		"pooling dilation":  strings.Replace(valid, "{type: dense, units: 1}", "{type: max_pool2d, size: 2, dilation: 2}", 1),
+		"dense dilation":    strings.Replace(valid, "units: 1", "units: 1, dilation: 2", 1),
+		"padding too large": strings.Replace(valid, "{type: dense, units: 1}", "{type: avg_pool2d, size: 2, padding: 2}", 1),
+		"kernel too large":  strings.Replace(mnistConfig, "kernel_size: 3", "kernel_size: 40", 1),
+	}
+	for name, c := range cases {
+		_, err := Parse([]byte(c))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestNetworkRejectsInvalidLayers(t *testing.T) {
+	cases := map[string]Layer{
+		"unknown type":     {Type: "transformer"},
+		"no units":         {Type: "dense"},
+		"conv of vector":   {Type: "conv2d", Filters: 2, KernelSize: 3},
+		"bad activation":   {Type: "dense", Units: 2, Activation: "cube"},
+		"bad dropout rate": {Type: "dropout", Rate: 1},
+	}
+	for name, l := range cases {
+		cfg := &Config{Model: []Layer{l}}
+		_, err := cfg.Network(4, nil)
+		assert.Error(t, err, name)
+	}
+
+	cfg := &Config{Data: Data{Shape: []int{1, 2, 2}}, Model: []Layer{{Type: "conv2d", Filters: 1, KernelSize: 5}}}
+	_, err := cfg.Network(4, nil)
+	assert.Error(t, err, "kernel larger than image")
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	r := rand.New(rand.NewSource(1))
+
+	var data strings.Builder
+	data.WriteString("a,b,target\n")
+	for i := 0; i < 100; i++ {
+		a, b := r.Float64(), r.Float64()
+		fmt.Fprintf(&data, "%f,%f,%f\n", a, b, 2*a-b)
+	}
+	dataPath := filepath.Join(dir, "data.csv")
+	require.NoError(t, ioutil.WriteFile(dataPath, []byte(data.String()), 0644))
+
+	output := filepath.Join(dir, "model.gonn")
+	cfg, err := Parse([]byte(fmt.Sprintf(`
+data: {format: csv, header: true, train: {inputs: %q}}
+model:
+  - {type: dense, units: 4, activation: tanh}
+  - {type: dense, units: 1}
+loss: l2
+optimizer: {type: momentum, learning_rate: 0.05}
+training: {batch_size: 10, epochs: 5, seed: 3, shuffle: true}
+metrics: [rmse, r2]
+output: %q
+`, dataPath, output)))
+	require.NoError(t, err)
+
+	history, err := Run(cfg)
+	require.NoError(t, err)
+	require.Len(t, history.Epochs, 5)
+	assert.Contains(t, history.Epochs[4].Metrics, "r2")
+
+	_, err = nn.LoadFile(output)
+	assert.NoError(t, err)
+}
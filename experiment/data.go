@@ -0,0 +1,100 @@
+package experiment
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/rosshemsley/gonn/mnist"
+	"gonum.org/v1/gonum/mat"
+)
+
+// LoadData loads the training data, and the validation data if the config gives any.
+// xVal and yVal are nil otherwise.
+func (c *Config) LoadData() (x, y, xVal, yVal *mat.Dense, err error) {
+	x, y, err = c.loadFiles(c.Data.Train)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to load training data: %s", err)
+	}
+
+	if c.Data.Validation != nil {
+		xVal, yVal, err = c.loadFiles(*c.Data.Validation)
+		if err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("failed to load validation data: %s", err)
+		}
+	}
+
+	return x, y, xVal, yVal, nil
+}
+
+func (c *Config) loadFiles(files Files) (x, y *mat.Dense, err error) {
+	switch c.Data.Format {
+	case "mnist":
+		x, err = mnist.LoadImagesGzipFile(files.Inputs)
+		if err != nil {
+			return nil, nil, err
+		}
+		classes := c.Data.Classes
+		if classes == 0 {
+			classes = mnist.Classes
+		}
+		y, err = mnist.LoadLabelsGzipFileWithClasses(files.Targets, classes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return x, y, nil
+	case "csv":
+		f, err := os.Open(files.Inputs)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer f.Close()
+
+		targetColumns := c.Data.TargetColumns
+		if targetColumns == 0 {
+			targetColumns = 1
+		}
+		return loadCSV(f, targetColumns, c.Data.Header)
+	default:
+		return nil, nil, fmt.Errorf("unknown format: %s", c.Data.Format)
+	}
+}
+
+// loadCSV reads rows of numbers, splitting off the last targetColumns of each row as the targets.
+func loadCSV(r io.Reader, targetColumns int, header bool) (x, y *mat.Dense, err error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if header && len(records) > 0 {
+		records = records[1:]
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("no rows")
+	}
+
+	cols := len(records[0])
+	if cols <= targetColumns {
+		return nil, nil, fmt.Errorf("expected more than %d columns, found %d", targetColumns, cols)
+	}
+
+	x = mat.NewDense(len(records), cols-targetColumns, nil)
+	y = mat.NewDense(len(records), targetColumns, nil)
+	for i, record := range records {
+		for j, field := range record {
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("row %d, column %d: %s", i+1, j+1, err)
+			}
+			if j < cols-targetColumns {
+				x.Set(i, j, v)
+			} else {
+				y.Set(i, j-(cols-targetColumns), v)
+			}
+		}
+	}
+
+	return x, y, nil
+}
@@ -0,0 +1,44 @@
+package experiment
+
+import (
+	"math/rand"
+
+	"github.com/rosshemsley/gonn/sgd"
+)
+
+// Run loads the data, builds and trains the network, and saves it to the output path.
+// Any extra settings, such as callbacks to report progress, are passed on to sgd.SGD.
+func Run(c *Config, extra ...sgd.Setting) (*sgd.History, error) {
+	x, y, xVal, yVal, err := c.LoadData()
+	if err != nil {
+		return nil, err
+	}
+
+	var rng *rand.Rand
+	if c.Training.Seed != nil {
+		rng = rand.New(rand.NewSource(*c.Training.Seed))
+	}
+
+	_, cols := x.Dims()
+	net, err := c.Network(cols, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	loss, err := c.loss()
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := c.settings(xVal, yVal)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := sgd.SGD(x, y, loss, net, append(settings, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return history, net.SaveFile(c.Output)
+}
@@ -12,4 +12,5 @@ require (
 	gonum.org/v1/gonum v0.0.0-20181029232933-400065bf7646
 	gonum.org/v1/netlib v0.0.0-20181029234149-ec6d1f5cefe6 // indirect
 	gopkg.in/alecthomas/kingpin.v2 v2.2.6
+	gopkg.in/yaml.v2 v2.4.0
 )
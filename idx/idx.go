@@ -0,0 +1,326 @@
+// Package idx reads and writes files in the IDX format, used by MNIST and the
+// datasets that follow its layout, such as EMNIST, Fashion-MNIST and KMNIST.
+//
+// An IDX file starts with a magic number whose third byte gives the type of the
+// elements and whose fourth byte gives the number of dimensions. The size of each
+// dimension follows as a big-endian 32 bit integer, and then the elements themselves,
+// big-endian and in row-major order.
+//
+// Files can be gzipped, as they usually are when they are distributed.
+package idx
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// chunkSize is the most elements read at once. The header of a corrupt or truncated
+// file can claim far more elements than it holds, so memory is only allocated as the
+// elements are actually read.
+const chunkSize = 1 << 16
+
+// Type is the type of the elements of an IDX file.
+type Type byte
+
+const (
+	UnsignedByte Type = 0x08
+	SignedByte   Type = 0x09
+	Short        Type = 0x0B
+	Int          Type = 0x0C
+	Float        Type = 0x0D
+	Double       Type = 0x0E
+)
+
+func (t Type) String() string {
+	switch t {
+	case UnsignedByte:
+		return "unsigned byte"
+	case SignedByte:
+		return "signed byte"
+	case Short:
+		return "short"
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case Double:
+		return "double"
+	default:
+		return fmt.Sprintf("unknown type 0x%02x", byte(t))
+	}
+}
+
+// Size returns the number of bytes used to store an element of the type, or 0 if it is unknown.
+func (t Type) Size() int {
+	switch t {
+	case UnsignedByte, SignedByte:
+		return 1
+	case Short:
+		return 2
+	case Int, Float:
+		return 4
+	case Double:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// Data is the contents of an IDX file.
+type Data struct {
+	// Type is the type the elements are stored as.
+	Type Type
+
+	// Dims is the size of each dimension. The first dimension usually indexes the examples.
+	Dims []int
+
+	// Values holds the elements in row-major order. They are not scaled.
+	Values []float64
+}
+
+// Len returns the size of the first dimension, e.g. the number of images in a file of images.
+func (d *Data) Len() int {
+	if len(d.Dims) == 0 {
+		return 0
+	}
+	return d.Dims[0]
+}
+
+// Matrix returns the data as a matrix with a row for each index of the first dimension,
+// with the remaining dimensions unrolled into the columns in row-major order.
+// One dimensional data gives a matrix with a single column.
+func (d *Data) Matrix() *mat.Dense {
+	rows := d.Len()
+	if rows == 0 {
+		panic("idx: cannot make a matrix from empty data")
+	}
+	return mat.NewDense(rows, len(d.Values)/rows, d.Values)
+}
+
+// ReadFile reads the IDX file at path, which is decompressed first if it is gzipped.
+func ReadFile(path string) (*Data, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Read reads an IDX file from r, which is decompressed first if it is gzipped.
+func Read(r io.Reader) (*Data, error) {
+	br := bufio.NewReader(r)
+
+	// The magic number of an IDX file starts with two zero bytes, so it cannot be mistaken for gzip.
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	if header[0] == 0x1f && header[1] == 0x8b {
+		rgz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer rgz.Close()
+
+		return read(bufio.NewReader(rgz))
+	}
+
+	return read(br)
+}
+
+func read(r io.Reader) (*Data, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+	if magic[0] != 0 || magic[1] != 0 {
+		return nil, fmt.Errorf("unexpected file format")
+	}
+
+	t := Type(magic[2])
+	if t.Size() == 0 {
+		return nil, fmt.Errorf("unsupported element type: %s", t)
+	}
+	if magic[3] == 0 {
+		return nil, fmt.Errorf("no dimensions")
+	}
+
+	dims := make([]int, magic[3])
+	n := 1
+	for i := range dims {
+		var dim uint32
+		if err := binary.Read(r, binary.BigEndian, &dim); err != nil {
+			return nil, err
+		}
+		dims[i] = int(dim)
+		if dims[i] != 0 && n > math.MaxInt32/dims[i] {
+			return nil, fmt.Errorf("too many elements: %v", dims)
+		}
+		n *= dims[i]
+	}
+
+	chunk := n
+	if chunk > chunkSize {
+		chunk = chunkSize
+	}
+
+	values := make([]float64, 0, chunk)
+	raw := make([]byte, chunk*t.Size())
+	for len(values) < n {
+		m := n - len(values)
+		if m > chunk {
+			m = chunk
+		}
+
+		if _, err := io.ReadFull(r, raw[:m*t.Size()]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		for i := 0; i < m; i++ {
+			values = append(values, decode(t, raw[i*t.Size():]))
+		}
+	}
+
+	return &Data{Type: t, Dims: dims, Values: values}, nil
+}
+
+func decode(t Type, b []byte) float64 {
+	switch t {
+	case UnsignedByte:
+		return float64(b[0])
+	case SignedByte:
+		return float64(int8(b[0]))
+	case Short:
+		return float64(int16(binary.BigEndian.Uint16(b)))
+	case Int:
+		return float64(int32(binary.BigEndian.Uint32(b)))
+	case Float:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+	case Double:
+		return math.Float64frombits(binary.BigEndian.Uint64(b))
+	default:
+		panic(fmt.Sprintf("idx: unsupported element type: %s", t))
+	}
+}
+
+// WriteFile writes d to the file at path, gzipping it if the path ends in .gz.
+func WriteFile(path string, d *Data) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(path, ".gz") {
+		wgz := gzip.NewWriter(f)
+		if err := Write(wgz, d); err != nil {
+			f.Close()
+			return err
+		}
+		if err := wgz.Close(); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	if err := Write(f, d); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Write writes d to w in the IDX format. Values must fit the type of d exactly,
+// so integer types can only hold whole numbers within their range.
+func Write(w io.Writer, d *Data) error {
+	if d.Type.Size() == 0 {
+		return fmt.Errorf("unsupported element type: %s", d.Type)
+	}
+	if len(d.Dims) == 0 || len(d.Dims) > math.MaxUint8 {
+		return fmt.Errorf("unsupported number of dimensions: %d", len(d.Dims))
+	}
+
+	n := 1
+	for _, dim := range d.Dims {
+		if dim < 0 || dim > math.MaxInt32 {
+			return fmt.Errorf("invalid dimension: %d", dim)
+		}
+		n *= dim
+	}
+	if n != len(d.Values) {
+		return fmt.Errorf("dimensions %v need %d values, found %d", d.Dims, n, len(d.Values))
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write([]byte{0, 0, byte(d.Type), byte(len(d.Dims))}); err != nil {
+		return err
+	}
+	for _, dim := range d.Dims {
+		if err := binary.Write(bw, binary.BigEndian, uint32(dim)); err != nil {
+			return err
+		}
+	}
+
+	b := make([]byte, d.Type.Size())
+	for i, v := range d.Values {
+		if err := encode(d.Type, v, b); err != nil {
+			return fmt.Errorf("value %d: %s", i, err)
+		}
+		if _, err := bw.Write(b); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+func encode(t Type, v float64, b []byte) error {
+	switch t {
+	case UnsignedByte:
+		if err := checkInteger(t, v, 0, math.MaxUint8); err != nil {
+			return err
+		}
+		b[0] = byte(v)
+	case SignedByte:
+		if err := checkInteger(t, v, math.MinInt8, math.MaxInt8); err != nil {
+			return err
+		}
+		b[0] = byte(int8(v))
+	case Short:
+		if err := checkInteger(t, v, math.MinInt16, math.MaxInt16); err != nil {
+			return err
+		}
+		binary.BigEndian.PutUint16(b, uint16(int16(v)))
+	case Int:
+		if err := checkInteger(t, v, math.MinInt32, math.MaxInt32); err != nil {
+			return err
+		}
+		binary.BigEndian.PutUint32(b, uint32(int32(v)))
+	case Float:
+		binary.BigEndian.PutUint32(b, math.Float32bits(float32(v)))
+	case Double:
+		binary.BigEndian.PutUint64(b, math.Float64bits(v))
+	default:
+		return fmt.Errorf("unsupported element type: %s", t)
+	}
+	return nil
+}
+
+func checkInteger(t Type, v, min, max float64) error {
+	if v != math.Trunc(v) || v < min || v > max {
+		return fmt.Errorf("%v cannot be stored as %s", v, t)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package idx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadMNISTLayout(t *testing.T) {
+	// Two 2x3 images of unsigned bytes, as in the MNIST image files.
+	b := []byte{
+		0, 0, 0x08, 3,
+		0, 0, 0, 2,
+		0, 0, 0, 2,
+		0, 0, 0, 3,
+		0, 1, 2, 3, 4, 5,
+		255, 254, 253, 252, 251, 250,
+	}
+
+	d, err := Read(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	assert.Equal(t, UnsignedByte, d.Type)
+	assert.Equal(t, []int{2, 2, 3}, d.Dims)
+	assert.Equal(t, 2, d.Len())
+	assert.Equal(t, []float64{0, 1, 2, 3, 4, 5, 255, 254, 253, 252, 251, 250}, d.Values)
+
+	m := d.Matrix()
+	rows, cols := m.Dims()
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, 6, cols)
+	assert.Equal(t, 253.0, m.At(1, 2))
+}
+
+func TestRoundTripEveryType(t *testing.T) {
+	values := map[Type][]float64{
+		UnsignedByte: {0, 1, 128, 255, 7, 9},
+		SignedByte:   {-128, -1, 0, 1, 64, 127},
+		Short:        {-32768, -300, 0, 300, 1000, 32767},
+		Int:          {-2147483648, -70000, 0, 70000, 1, 2147483647},
+		Float:        {-1.5, 0, 0.25, 3.125, 1e10, -2},
+		Double:       {-1.1, 0, 0.1, 3.14159, 1e300, -2e-300},
+	}
+
+	for typ, vs := range values {
+		d := &Data{Type: typ, Dims: []int{3, 2}, Values: vs}
+
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, d), typ.String())
+		assert.Equal(t, 4+2*4+len(vs)*typ.Size(), buf.Len(), typ.String())
+
+		read, err := Read(&buf)
+		require.NoError(t, err, typ.String())
+		assert.Equal(t, d, read, typ.String())
+	}
+}
+
+func TestReadGzip(t *testing.T) {
+	d := &Data{Type: Short, Dims: []int{4}, Values: []float64{1, -2, 3, -4}}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	require.NoError(t, Write(w, d))
+	require.NoError(t, w.Close())
+
+	read, err := Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, d, read)
+}
+
+func TestFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idx")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	d := &Data{Type: UnsignedByte, Dims: []int{2, 1, 2}, Values: []float64{1, 2, 3, 4}}
+	for _, name := range []string{"data-idx3-ubyte", "data-idx3-ubyte.gz"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, WriteFile(path, d))
+
+		read, err := ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, d, read)
+	}
+}
+
+func TestWriteRejectsValuesThatDoNotFit(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Error(t, Write(&buf, &Data{Type: UnsignedByte, Dims: []int{1}, Values: []float64{256}}))
+	assert.Error(t, Write(&buf, &Data{Type: SignedByte, Dims: []int{1}, Values: []float64{-129}}))
+	assert.Error(t, Write(&buf, &Data{Type: Int, Dims: []int{1}, Values: []float64{0.5}}))
+	assert.Error(t, Write(&buf, &Data{Type: Double, Dims: []int{2}, Values: []float64{1}}))
+	assert.Error(t, Write(&buf, &Data{Type: Type(0x42), Dims: []int{1}, Values: []float64{1}}))
+	assert.Error(t, Write(&buf, &Data{Type: Double}))
+}
+
+func TestReadTruncatedFiles(t *testing.T) {
+	for name, b := range map[string][]byte{
+		"no elements":   {0, 0, 0x08, 1, 0, 0, 0, 1},
+		"partial":       {0, 0, 0x0C, 1, 0, 0, 0, 2, 0, 0, 0, 1, 0, 0},
+		"huge header":   {0, 0, 0x0E, 2, 0, 0, 0x80, 0, 0, 0, 0x80, 0, 0},
+		"after a chunk": append([]byte{0, 0, 0x08, 1, 0, 2, 0, 0}, make([]byte, chunkSize)...),
+	} {
+		_, err := Read(bytes.NewReader(b))
+		assert.Equal(t, io.ErrUnexpectedEOF, err, name)
+	}
+
+	// Files larger than a chunk are read whole.
+	d, err := Read(bytes.NewReader(append([]byte{0, 0, 0x08, 1, 0, 1, 0, 1}, make([]byte, chunkSize+1)...)))
+	require.NoError(t, err)
+	assert.Len(t, d.Values, chunkSize+1)
+}
+
+func TestReadRejectsInvalidFiles(t *testing.T) {
+	for name, b := range map[string][]byte{
+		"bad magic":    {1, 0, 0x08, 1, 0, 0, 0, 1, 0},
+		"unknown type": {0, 0, 0x42, 1, 0, 0, 0, 1, 0},
+		"no dims":      {0, 0, 0x08, 0},
+		"truncated":    {0, 0, 0x0C, 1, 0, 0, 0, 2, 0, 0, 0, 1},
+		"too large":    {0, 0, 0x08, 2, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	} {
+		_, err := Read(bytes.NewReader(b))
+		assert.Error(t, err, name)
+	}
+}
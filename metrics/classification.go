@@ -0,0 +1,343 @@
+// Package metrics scores the predictions of a network against its targets.
+//
+// Each metric has the signature func(y, yHat *mat.Dense) float64, with the targets y
+// first, so that it can be passed to sgd.WithMetric to be reported every epoch.
+//
+// Classification metrics take one-hot targets, and predicted scores (such as the output of
+// a softmax) with a column per class. The predicted class of a row is its highest scoring column.
+// A single column is treated as the probability of the positive class of a binary problem.
+//
+// Regression metrics compare every entry of the predictions with the matching target.
+// Scores that are computed per column (R² and explained variance) are averaged over the columns.
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Average says how a per-class metric is combined into a single score.
+type Average int
+
+const (
+	// Micro pools the counts of every class before computing the metric.
+	Micro Average = iota
+
+	// Macro takes the unweighted mean of the metric for each class.
+	Macro
+
+	// Weighted takes the mean of the metric for each class, weighted by the number of
+	// rows of that class in the targets.
+	Weighted
+)
+
+// logLossEpsilon bounds the predicted probabilities away from 0 and 1 in LogLoss.
+const logLossEpsilon = 1e-15
+
+// Accuracy returns the proportion of rows whose predicted class matches the target.
+func Accuracy(y, yHat *mat.Dense) float64 {
+	actual, predicted := classes(y, yHat)
+
+	correct := 0
+	for i := range actual {
+		if actual[i] == predicted[i] {
+			correct++
+		}
+	}
+
+	return float64(correct) / float64(len(actual))
+}
+
+// TopKAccuracy returns a metric giving the proportion of rows whose target class is
+// among the k highest scoring predictions.
+func TopKAccuracy(k int) func(y, yHat *mat.Dense) float64 {
+	return func(y, yHat *mat.Dense) float64 {
+		checkDims(y, yHat)
+		actual := labels(y)
+		rows, cols := yHat.Dims()
+
+		correct := 0
+		for i := 0; i < rows; i++ {
+			row := yHat.RawRowView(i)
+			target := row[actual[i]]
+
+			// Count the classes that score higher, breaking ties in favour of the lower index.
+			higher := 0
+			for j := 0; j < cols; j++ {
+				if row[j] > target || (row[j] == target && j < actual[i]) {
+					higher++
+				}
+			}
+			if higher < k {
+				correct++
+			}
+		}
+
+		return float64(correct) / float64(rows)
+	}
+}
+
+// ConfusionMatrix counts how often each class is predicted for each target class.
+// Entry (i, j) is the number of rows of class i that were predicted as class j.
+func ConfusionMatrix(y, yHat *mat.Dense) *mat.Dense {
+	actual, predicted := classes(y, yHat)
+	n := numClasses(y)
+
+	result := mat.NewDense(n, n, nil)
+	for i := range actual {
+		result.Set(actual[i], predicted[i], result.At(actual[i], predicted[i])+1)
+	}
+
+	return result
+}
+
+// ClassScores are the scores of a single class, and its number of rows in the targets.
+type ClassScores struct {
+	Precision float64
+	Recall    float64
+	F1        float64
+	Support   int
+}
+
+// PerClass returns the precision, recall and F1 score of each class, indexed by class.
+func PerClass(y, yHat *mat.Dense) []ClassScores {
+	confusion := ConfusionMatrix(y, yHat)
+	n, _ := confusion.Dims()
+
+	result := make([]ClassScores, n)
+	for c := range result {
+		tp := confusion.At(c, c)
+		support := mat.Sum(confusion.RowView(c))
+		predicted := mat.Sum(confusion.ColView(c))
+
+		result[c] = ClassScores{
+			Precision: ratio(tp, predicted),
+			Recall:    ratio(tp, support),
+			F1:        ratio(2*tp, predicted+support),
+			Support:   int(support),
+		}
+	}
+
+	return result
+}
+
+// Precision returns a metric giving the proportion of predictions of each class that are correct.
+// A class that is never predicted has a precision of zero.
+func Precision(average Average) func(y, yHat *mat.Dense) float64 {
+	return func(y, yHat *mat.Dense) float64 {
+		return averaged(ConfusionMatrix(y, yHat), average, func(tp, fp, fn float64) float64 {
+			return ratio(tp, tp+fp)
+		})
+	}
+}
+
+// Recall returns a metric giving the proportion of the rows of each class that are predicted correctly.
+func Recall(average Average) func(y, yHat *mat.Dense) float64 {
+	return func(y, yHat *mat.Dense) float64 {
+		return averaged(ConfusionMatrix(y, yHat), average, func(tp, fp, fn float64) float64 {
+			return ratio(tp, tp+fn)
+		})
+	}
+}
+
+// F1 returns a metric giving the harmonic mean of the precision and recall of each class.
+func F1(average Average) func(y, yHat *mat.Dense) float64 {
+	return func(y, yHat *mat.Dense) float64 {
+		return averaged(ConfusionMatrix(y, yHat), average, func(tp, fp, fn float64) float64 {
+			return ratio(2*tp, 2*tp+fp+fn)
+		})
+	}
+}
+
+// ROCAUC returns the area under the receiver operating characteristic curve.
+// With more than one column, it is the mean over classes of the area for that class
+// against the rest, skipping classes whose targets are all the same.
+func ROCAUC(y, yHat *mat.Dense) float64 {
+	checkDims(y, yHat)
+	rows, cols := y.Dims()
+
+	if cols == 1 {
+		positive := make([]bool, rows)
+		for i := range positive {
+			positive[i] = y.At(i, 0) >= 0.5
+		}
+		return auc(positive, mat.Col(nil, 0, yHat))
+	}
+
+	actual := labels(y)
+	total, n := 0.0, 0
+	for c := 0; c < cols; c++ {
+		positive := make([]bool, rows)
+		for i := range positive {
+			positive[i] = actual[i] == c
+		}
+
+		a := auc(positive, mat.Col(nil, c, yHat))
+		if !math.IsNaN(a) {
+			total += a
+			n++
+		}
+	}
+
+	if n == 0 {
+		return math.NaN()
+	}
+	return total / float64(n)
+}
+
+// LogLoss returns the mean cross-entropy between the targets and the predicted probabilities.
+// A single column is scored as the probability of the positive class.
+func LogLoss(y, yHat *mat.Dense) float64 {
+	checkDims(y, yHat)
+	rows, cols := y.Dims()
+
+	loss := 0.0
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			p := math.Min(math.Max(yHat.At(i, j), logLossEpsilon), 1-logLossEpsilon)
+			loss -= y.At(i, j) * math.Log(p)
+			if cols == 1 {
+				loss -= (1 - y.At(i, j)) * math.Log(1-p)
+			}
+		}
+	}
+
+	return loss / float64(rows)
+}
+
+// averaged combines the score of each class of a confusion matrix, where score
+// is computed from the true positives, false positives and false negatives.
+func averaged(confusion *mat.Dense, average Average, score func(tp, fp, fn float64) float64) float64 {
+	n, _ := confusion.Dims()
+	var tps, fps, fns, supports []float64
+	var total float64
+
+	for c := 0; c < n; c++ {
+		tp := confusion.At(c, c)
+		support := mat.Sum(confusion.RowView(c))
+		predicted := mat.Sum(confusion.ColView(c))
+
+		tps = append(tps, tp)
+		fps = append(fps, predicted-tp)
+		fns = append(fns, support-tp)
+		supports = append(supports, support)
+		total += support
+	}
+
+	switch average {
+	case Micro:
+		return score(sum(tps), sum(fps), sum(fns))
+	case Macro:
+		result := 0.0
+		for c := range tps {
+			result += score(tps[c], fps[c], fns[c]) / float64(n)
+		}
+		return result
+	case Weighted:
+		result := 0.0
+		for c := range tps {
+			result += score(tps[c], fps[c], fns[c]) * supports[c] / total
+		}
+		return result
+	default:
+		panic(fmt.Sprintf("unknown average: %d", average))
+	}
+}
+
+// auc returns the probability that a random positive scores higher than a random negative,
+// counting ties as half. Returns NaN when there are no positives or no negatives.
+func auc(positive []bool, scores []float64) float64 {
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return scores[order[a]] < scores[order[b]]
+	})
+
+	// Sum the ranks of the positives, giving tied scores their average rank.
+	var rankSum, nPos float64
+	for i := 0; i < len(order); {
+		j := i
+		for j < len(order) && scores[order[j]] == scores[order[i]] {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for _, k := range order[i:j] {
+			if positive[k] {
+				rankSum += rank
+				nPos++
+			}
+		}
+		i = j
+	}
+
+	nNeg := float64(len(scores)) - nPos
+	if nPos == 0 || nNeg == 0 {
+		return math.NaN()
+	}
+	return (rankSum - nPos*(nPos+1)/2) / (nPos * nNeg)
+}
+
+func classes(y, yHat *mat.Dense) (actual, predicted []int) {
+	checkDims(y, yHat)
+	return labels(y), labels(yHat)
+}
+
+// labels returns the class of each row of m.
+func labels(m *mat.Dense) []int {
+	rows, cols := m.Dims()
+	result := make([]int, rows)
+
+	for i := range result {
+		row := m.RawRowView(i)
+		if cols == 1 {
+			if row[0] >= 0.5 {
+				result[i] = 1
+			}
+			continue
+		}
+
+		for j := range row {
+			if row[j] > row[result[i]] {
+				result[i] = j
+			}
+		}
+	}
+
+	return result
+}
+
+func numClasses(y *mat.Dense) int {
+	_, cols := y.Dims()
+	if cols == 1 {
+		return 2
+	}
+	return cols
+}
+
+func checkDims(y, yHat *mat.Dense) {
+	yRows, yCols := y.Dims()
+	rows, cols := yHat.Dims()
+	if yRows != rows || yCols != cols {
+		panic(fmt.Sprintf("mismatch in dimensions: %dx%d != %dx%d", yRows, yCols, rows, cols))
+	}
+}
+
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+func sum(vs []float64) float64 {
+	total := 0.0
+	for _, v := range vs {
+		total += v
+	}
+	return total
+}
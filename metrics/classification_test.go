@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Three classes, with targets 0, 0, 1, 2, 2 and predictions 0, 1, 1, 2, 0.
+var (
+	targets = mat.NewDense(5, 3, []float64{
+		1, 0, 0,
+		1, 0, 0,
+		0, 1, 0,
+		0, 0, 1,
+		0, 0, 1,
+	})
+	predictions = mat.NewDense(5, 3, []float64{
+		0.7, 0.2, 0.1,
+		0.3, 0.6, 0.1,
+		0.2, 0.5, 0.3,
+		0.1, 0.1, 0.8,
+		0.5, 0.1, 0.4,
+	})
+)
+
+func TestAccuracy(t *testing.T) {
+	assert.InDelta(t, 0.6, Accuracy(targets, predictions), 1e-12)
+	assert.InDelta(t, 1.0, Accuracy(targets, targets), 1e-12)
+}
+
+func TestTopKAccuracy(t *testing.T) {
+	assert.InDelta(t, 0.6, TopKAccuracy(1)(targets, predictions), 1e-12)
+	assert.InDelta(t, 1.0, TopKAccuracy(2)(targets, predictions), 1e-12)
+	assert.InDelta(t, 1.0, TopKAccuracy(3)(targets, predictions), 1e-12)
+}
+
+func TestConfusionMatrix(t *testing.T) {
+	expected := mat.NewDense(3, 3, []float64{
+		1, 1, 0,
+		0, 1, 0,
+		1, 0, 1,
+	})
+	assert.True(t, mat.Equal(expected, ConfusionMatrix(targets, predictions)))
+}
+
+func TestPrecisionRecallF1(t *testing.T) {
+	// Per class: precision 1/2, 1/2, 1; recall 1/2, 1, 1/2; supports 2, 1, 2.
+	precision := []float64{0.5, 0.5, 1}
+	recall := []float64{0.5, 1, 0.5}
+	f1 := make([]float64, 3)
+	for c := range f1 {
+		f1[c] = 2 * precision[c] * recall[c] / (precision[c] + recall[c])
+	}
+
+	macro := func(vs []float64) float64 { return (vs[0] + vs[1] + vs[2]) / 3 }
+	weighted := func(vs []float64) float64 { return (2*vs[0] + vs[1] + 2*vs[2]) / 5 }
+
+	assert.InDelta(t, 0.6, Precision(Micro)(targets, predictions), 1e-12)
+	assert.InDelta(t, 0.6, Recall(Micro)(targets, predictions), 1e-12)
+	assert.InDelta(t, 0.6, F1(Micro)(targets, predictions), 1e-12)
+
+	assert.InDelta(t, macro(precision), Precision(Macro)(targets, predictions), 1e-12)
+	assert.InDelta(t, macro(recall), Recall(Macro)(targets, predictions), 1e-12)
+	assert.InDelta(t, macro(f1), F1(Macro)(targets, predictions), 1e-12)
+
+	assert.InDelta(t, weighted(precision), Precision(Weighted)(targets, predictions), 1e-12)
+	assert.InDelta(t, weighted(recall), Recall(Weighted)(targets, predictions), 1e-12)
+	assert.InDelta(t, weighted(f1), F1(Weighted)(targets, predictions), 1e-12)
+}
+
+func TestPerClass(t *testing.T) {
+	scores := PerClass(targets, predictions)
+	expected := []ClassScores{
+		{Precision: 0.5, Recall: 0.5, F1: 0.5, Support: 2},
+		{Precision: 0.5, Recall: 1, F1: 2.0 / 3, Support: 1},
+		{Precision: 1, Recall: 0.5, F1: 2.0 / 3, Support: 2},
+	}
+
+	assert.Len(t, scores, len(expected))
+	for c := range expected {
+		assert.InDelta(t, expected[c].Precision, scores[c].Precision, 1e-12)
+		assert.InDelta(t, expected[c].Recall, scores[c].Recall, 1e-12)
+		assert.InDelta(t, expected[c].F1, scores[c].F1, 1e-12)
+		assert.Equal(t, expected[c].Support, scores[c].Support)
+	}
+}
+
+func TestROCAUC(t *testing.T) {
+	y := mat.NewDense(4, 1, []float64{0, 0, 1, 1})
+
+	perfect := mat.NewDense(4, 1, []float64{0.1, 0.2, 0.8, 0.9})
+	assert.InDelta(t, 1.0, ROCAUC(y, perfect), 1e-12)
+
+	// One of the four positive/negative pairs is ordered wrongly.
+	mixed := mat.NewDense(4, 1, []float64{0.1, 0.6, 0.4, 0.9})
+	assert.InDelta(t, 0.75, ROCAUC(y, mixed), 1e-12)
+
+	ties := mat.NewDense(4, 1, []float64{0.5, 0.5, 0.5, 0.5})
+	assert.InDelta(t, 0.5, ROCAUC(y, ties), 1e-12)
+
+	assert.True(t, math.IsNaN(ROCAUC(mat.NewDense(2, 1, []float64{1, 1}), mat.NewDense(2, 1, []float64{0.2, 0.3}))))
+	assert.InDelta(t, 1.0, ROCAUC(targets, targets), 1e-12)
+}
+
+func TestLogLoss(t *testing.T) {
+	y := mat.NewDense(2, 2, []float64{1, 0, 0, 1})
+	yHat := mat.NewDense(2, 2, []float64{0.8, 0.2, 0.4, 0.6})
+	assert.InDelta(t, -(math.Log(0.8)+math.Log(0.6))/2, LogLoss(y, yHat), 1e-12)
+
+	binary := mat.NewDense(2, 1, []float64{1, 0})
+	binaryHat := mat.NewDense(2, 1, []float64{0.8, 0.4})
+	assert.InDelta(t, -(math.Log(0.8)+math.Log(0.6))/2, LogLoss(binary, binaryHat), 1e-12)
+
+	assert.False(t, math.IsInf(LogLoss(y, mat.NewDense(2, 2, []float64{0, 1, 1, 0})), 0))
+}
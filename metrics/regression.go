@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// MSE returns the mean squared error.
+func MSE(y, yHat *mat.Dense) float64 {
+	checkDims(y, yHat)
+	rows, cols := y.Dims()
+
+	total := 0.0
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			d := y.At(i, j) - yHat.At(i, j)
+			total += d * d
+		}
+	}
+
+	return total / float64(rows*cols)
+}
+
+// RMSE returns the square root of the mean squared error.
+func RMSE(y, yHat *mat.Dense) float64 {
+	return math.Sqrt(MSE(y, yHat))
+}
+
+// MAE returns the mean absolute error.
+func MAE(y, yHat *mat.Dense) float64 {
+	checkDims(y, yHat)
+	rows, cols := y.Dims()
+
+	total := 0.0
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			total += math.Abs(y.At(i, j) - yHat.At(i, j))
+		}
+	}
+
+	return total / float64(rows*cols)
+}
+
+// R2 returns the coefficient of determination, 1 - SS_res / SS_tot.
+// A perfect fit scores 1, and always predicting the mean of the targets scores 0.
+// A column of constant targets scores 1 if it is predicted exactly, and 0 otherwise.
+func R2(y, yHat *mat.Dense) float64 {
+	return perColumn(y, yHat, func(target, residual []float64) float64 {
+		_, variance := meanVariance(target)
+		ssRes := 0.0
+		for _, r := range residual {
+			ssRes += r * r
+		}
+		return explained(ssRes, variance*float64(len(target)))
+	})
+}
+
+// ExplainedVariance returns 1 - Var(y - yHat) / Var(y). Unlike R2, it does not
+// penalize predictions that are consistently offset from the targets.
+// A column of constant targets scores 1 if the residuals are constant, and 0 otherwise.
+func ExplainedVariance(y, yHat *mat.Dense) float64 {
+	return perColumn(y, yHat, func(target, residual []float64) float64 {
+		_, varTarget := meanVariance(target)
+		_, varResidual := meanVariance(residual)
+		return explained(varResidual, varTarget)
+	})
+}
+
+// explained returns 1 - unexplained / total, the fraction of the total variation that is explained.
+// When there is no variation in the targets the ratio is undefined, so the score is 1 if
+// none is left unexplained and 0 otherwise, rather than NaN or infinite.
+func explained(unexplained, total float64) float64 {
+	if total == 0 {
+		if unexplained == 0 {
+			return 1
+		}
+		return 0
+	}
+	return 1 - unexplained/total
+}
+
+// perColumn averages score over the columns, given the targets and residuals of each column.
+func perColumn(y, yHat *mat.Dense, score func(target, residual []float64) float64) float64 {
+	checkDims(y, yHat)
+	rows, cols := y.Dims()
+
+	total := 0.0
+	for j := 0; j < cols; j++ {
+		target := mat.Col(nil, j, y)
+		residual := make([]float64, rows)
+		for i := range residual {
+			residual[i] = target[i] - yHat.At(i, j)
+		}
+		total += score(target, residual)
+	}
+
+	return total / float64(cols)
+}
+
+// meanVariance returns the mean and (biased) variance of vs.
+func meanVariance(vs []float64) (mean, variance float64) {
+	for _, v := range vs {
+		mean += v / float64(len(vs))
+	}
+	for _, v := range vs {
+		variance += (v - mean) * (v - mean) / float64(len(vs))
+	}
+	return mean, variance
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestRegressionMetrics(t *testing.T) {
+	y := mat.NewDense(4, 1, []float64{1, 2, 3, 4})
+	yHat := mat.NewDense(4, 1, []float64{1.5, 2, 2, 4})
+
+	// Residuals are -0.5, 0, 1, 0 and the targets have a variance of 1.25.
+	assert.InDelta(t, 1.25/4, MSE(y, yHat), 1e-12)
+	assert.InDelta(t, math.Sqrt(1.25/4), RMSE(y, yHat), 1e-12)
+	assert.InDelta(t, 1.5/4, MAE(y, yHat), 1e-12)
+	assert.InDelta(t, 1-1.25/5, R2(y, yHat), 1e-12)
+	assert.InDelta(t, 1-0.296875/1.25, ExplainedVariance(y, yHat), 1e-12)
+}
+
+func TestR2AndExplainedVariance(t *testing.T) {
+	y := mat.NewDense(3, 2, []float64{
+		1, 10,
+		2, 20,
+		3, 30,
+	})
+
+	assert.InDelta(t, 1, R2(y, y), 1e-12)
+
+	// Predicting the mean of each column scores zero.
+	mean := mat.NewDense(3, 2, []float64{2, 20, 2, 20, 2, 20})
+	assert.InDelta(t, 0, R2(y, mean), 1e-12)
+
+	// A constant offset is penalized by R2 but not by the explained variance.
+	offset := mat.NewDense(3, 2, nil)
+	offset.Apply(func(_, _ int, v float64) float64 { return v + 1 }, y)
+	assert.True(t, R2(y, offset) < 1)
+	assert.InDelta(t, 1, ExplainedVariance(y, offset), 1e-12)
+}
+
+func TestR2AndExplainedVarianceOfConstantTargets(t *testing.T) {
+	y := mat.NewDense(3, 1, []float64{2, 2, 2})
+
+	assert.Equal(t, 1.0, R2(y, y))
+	assert.Equal(t, 1.0, ExplainedVariance(y, y))
+
+	wrong := mat.NewDense(3, 1, []float64{1, 2, 3})
+	assert.Equal(t, 0.0, R2(y, wrong))
+	assert.Equal(t, 0.0, ExplainedVariance(y, wrong))
+
+	// A constant offset leaves no variance unexplained.
+	offset := mat.NewDense(3, 1, []float64{3, 3, 3})
+	assert.Equal(t, 0.0, R2(y, offset))
+	assert.Equal(t, 1.0, ExplainedVariance(y, offset))
+}
@@ -2,7 +2,6 @@ package mnist
 
 import (
 	"compress/gzip"
-	"encoding/binary"
 	"fmt"
 	"image"
 	"image/color"
@@ -10,9 +9,11 @@ import (
 	"io"
 	"os"
 
+	"github.com/rosshemsley/gonn/idx"
 	"gonum.org/v1/gonum/mat"
 )
 
+// LoadImagesGzipFile loads images from a gzipped IDX file, see LoadImages.
 func LoadImagesGzipFile(path string) (*mat.Dense, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -29,7 +30,16 @@ func LoadImagesGzipFile(path string) (*mat.Dense, error) {
 	return LoadImages(rgz)
 }
 
+// Classes is the number of classes of the MNIST digits.
+const Classes = 10
+
+// LoadLabelsGzipFile loads labels from a gzipped IDX file, see LoadLabels.
 func LoadLabelsGzipFile(path string) (*mat.Dense, error) {
+	return LoadLabelsGzipFileWithClasses(path, Classes)
+}
+
+// LoadLabelsGzipFileWithClasses loads labels from a gzipped IDX file, see LoadLabelsWithClasses.
+func LoadLabelsGzipFileWithClasses(path string, classes int) (*mat.Dense, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -42,86 +52,58 @@ func LoadLabelsGzipFile(path string) (*mat.Dense, error) {
 	}
 	defer rgz.Close()
 
-	return LoadLabels(rgz)
+	return LoadLabelsWithClasses(rgz, classes)
 }
 
-// LoadImages reads images for mnist data.
-// Returns a matrix where each row contains a new image.
-// Images are unrolled into vector of length 28*28 from a row-major matrix.
+// LoadImages reads images for mnist data, or any other dataset stored in the same
+// layout, such as Fashion-MNIST, KMNIST or EMNIST: an IDX file of unsigned bytes with
+// the dimensions (images, rows, cols).
+// Returns a matrix where each row contains a new image, scaled to [0, 1].
+// Images are unrolled into vector of length rows*cols from a row-major matrix.
 func LoadImages(r io.Reader) (*mat.Dense, error) {
-	var magic, n, rows, cols int32
-
-	err := binary.Read(r, binary.BigEndian, &magic)
+	d, err := idx.Read(r)
 	if err != nil {
 		return nil, err
 	}
-	if magic != 2051 {
-		return nil, fmt.Errorf("unexpected file format")
+	if d.Type != idx.UnsignedByte || len(d.Dims) != 3 {
+		return nil, fmt.Errorf("unexpected file format: expected images of unsigned bytes, found %d dimensions of %s", len(d.Dims), d.Type)
 	}
-
-	err = binary.Read(r, binary.BigEndian, &n)
-	if err != nil {
-		return nil, err
-	}
-	err = binary.Read(r, binary.BigEndian, &rows)
-	if err != nil {
-		return nil, err
-	}
-	err = binary.Read(r, binary.BigEndian, &cols)
-	if err != nil {
-		return nil, err
+	if d.Len() == 0 || d.Dims[1] == 0 || d.Dims[2] == 0 {
+		return nil, fmt.Errorf("no images")
 	}
 
-	if rows != 28 || cols != 28 {
-		return nil, fmt.Errorf("unpexected image size: %dX%d", rows, cols)
+	for i, v := range d.Values {
+		d.Values[i] = v / 255
 	}
 
-	result := mat.NewDense(int(n), int(rows*cols), nil)
-	for i := 0; i < int(n); i++ {
-		img := make([]byte, rows*cols)
-		imgVals := make([]float64, rows*cols)
-		err := binary.Read(r, binary.BigEndian, &img)
-		if err != nil {
-			return nil, err
-		}
-
-		for i, v := range img {
-			imgVals[i] = float64(v) / 255
-		}
-
-		result.SetRow(i, imgVals)
-	}
-
-	return result, nil
+	return d.Matrix(), nil
 }
 
 // LoadLabels returns a matrix with a row for each label loaded.
 // The labels are encoded with one-hot encoding, and so each column has 10 entries.
 func LoadLabels(r io.Reader) (*mat.Dense, error) {
-	var magic, n int32
+	return LoadLabelsWithClasses(r, Classes)
+}
 
-	err := binary.Read(r, binary.BigEndian, &magic)
+// LoadLabelsWithClasses loads labels like LoadLabels, for datasets with a different number
+// of classes, such as the letters of EMNIST. The labels must be in [0, classes).
+// The labels can be stored as any integer type.
+func LoadLabelsWithClasses(r io.Reader, classes int) (*mat.Dense, error) {
+	d, err := idx.Read(r)
 	if err != nil {
 		return nil, err
 	}
-	if magic != 2049 {
-		return nil, fmt.Errorf("unexpected file format")
+	if len(d.Dims) != 1 || (d.Type != idx.UnsignedByte && d.Type != idx.SignedByte && d.Type != idx.Short && d.Type != idx.Int) {
+		return nil, fmt.Errorf("unexpected file format: expected labels of integers, found %d dimensions of %s", len(d.Dims), d.Type)
 	}
-
-	err = binary.Read(r, binary.BigEndian, &n)
-	if err != nil {
-		return nil, err
+	if d.Len() == 0 {
+		return nil, fmt.Errorf("no labels")
 	}
 
-	result := mat.NewDense(int(n), 10, nil)
-	for i := 0; i < int(n); i++ {
-		var v int8
-		err := binary.Read(r, binary.BigEndian, &v)
-		if err != nil {
-			return nil, err
-		}
-		if v < 0 || v > 9 {
-			return nil, fmt.Errorf("invalid label: %d", v)
+	result := mat.NewDense(d.Len(), classes, nil)
+	for i, v := range d.Values {
+		if v < 0 || int(v) >= classes {
+			return nil, fmt.Errorf("invalid label: %v", v)
 		}
 		result.Set(i, int(v), 1)
 	}
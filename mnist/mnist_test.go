@@ -0,0 +1,71 @@
+package mnist
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rosshemsley/gonn/idx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encode(t *testing.T, d *idx.Data) *bytes.Buffer {
+	var buf bytes.Buffer
+	require.NoError(t, idx.Write(&buf, d))
+	return &buf
+}
+
+func TestLoadImagesOfAnySize(t *testing.T) {
+	buf := encode(t, &idx.Data{Type: idx.UnsignedByte, Dims: []int{2, 2, 3}, Values: []float64{
+		0, 51, 102, 153, 204, 255,
+		255, 0, 0, 0, 0, 0,
+	}})
+
+	x, err := LoadImages(buf)
+	require.NoError(t, err)
+
+	rows, cols := x.Dims()
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, 6, cols)
+	assert.InDeltaSlice(t, []float64{0, 0.2, 0.4, 0.6, 0.8, 1}, x.RawRowView(0), 1e-12)
+	assert.Equal(t, 1.0, x.At(1, 0))
+}
+
+func TestLoadImagesRejectsOtherData(t *testing.T) {
+	_, err := LoadImages(encode(t, &idx.Data{Type: idx.UnsignedByte, Dims: []int{2}, Values: []float64{1, 2}}))
+	assert.Error(t, err)
+
+	_, err = LoadImages(encode(t, &idx.Data{Type: idx.Float, Dims: []int{1, 1, 1}, Values: []float64{0.5}}))
+	assert.Error(t, err)
+}
+
+func TestLoadLabels(t *testing.T) {
+	// The width does not depend on which labels appear.
+	y, err := LoadLabels(encode(t, &idx.Data{Type: idx.UnsignedByte, Dims: []int{2}, Values: []float64{2, 0}}))
+	require.NoError(t, err)
+
+	rows, cols := y.Dims()
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, Classes, cols)
+	assert.Equal(t, 2, LabelValue(y.RawRowView(0)))
+	assert.Equal(t, 0, LabelValue(y.RawRowView(1)))
+
+	_, err = LoadLabels(encode(t, &idx.Data{Type: idx.UnsignedByte, Dims: []int{1}, Values: []float64{10}}))
+	assert.Error(t, err)
+
+	_, err = LoadLabels(encode(t, &idx.Data{Type: idx.SignedByte, Dims: []int{1}, Values: []float64{-1}}))
+	assert.Error(t, err)
+}
+
+func TestLoadLabelsWithClasses(t *testing.T) {
+	y, err := LoadLabelsWithClasses(encode(t, &idx.Data{Type: idx.Short, Dims: []int{3}, Values: []float64{2, 0, 25}}), 27)
+	require.NoError(t, err)
+
+	rows, cols := y.Dims()
+	assert.Equal(t, 3, rows)
+	assert.Equal(t, 27, cols)
+	assert.Equal(t, 25, LabelValue(y.RawRowView(2)))
+
+	_, err = LoadLabelsWithClasses(encode(t, &idx.Data{Type: idx.UnsignedByte, Dims: []int{1}, Values: []float64{27}}), 27)
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package mnist
+
+import (
+	"image"
+	"image/png"
+	"io"
+	"os"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const imageSize = 28
+
+// LoadPNGFile reads the PNG at path as an MNIST image, see ReadPNG.
+func LoadPNGFile(path string) (*mat.Dense, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadPNG(f)
+}
+
+// ReadPNG decodes a PNG and rasterizes it to the layout produced by LoadImages,
+// returning a matrix with a single row. See Rasterize.
+func ReadPNG(r io.Reader) (*mat.Dense, error) {
+	img, err := png.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return Rasterize(img), nil
+}
+
+// Rasterize converts an image to a 28x28 grayscale image in the layout produced by LoadImages:
+// a single row of values in [0, 1], unrolled from a row-major matrix.
+//
+// Images of other sizes are stretched to 28x28, averaging the pixels that fall into each cell.
+// Transparent pixels are drawn on white. MNIST digits are light on a dark background, so
+// images that are mostly light, such as dark ink on paper, are inverted.
+func Rasterize(img image.Image) *mat.Dense {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	vals := make([]float64, imageSize*imageSize)
+	mean := 0.0
+	for y := 0; y < imageSize; y++ {
+		y0, y1 := cell(y, height)
+		for x := 0; x < imageSize; x++ {
+			x0, x1 := cell(x, width)
+
+			total := 0.0
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					total += gray(img, bounds.Min.X+sx, bounds.Min.Y+sy)
+				}
+			}
+
+			v := total / float64((x1-x0)*(y1-y0))
+			vals[y*imageSize+x] = v
+			mean += v / float64(len(vals))
+		}
+	}
+
+	if mean > 0.5 {
+		for i, v := range vals {
+			vals[i] = 1 - v
+		}
+	}
+
+	return mat.NewDense(1, len(vals), vals)
+}
+
+// cell returns the range of source pixels that fall into cell i of 28,
+// for a source with n pixels. Every cell covers at least one pixel.
+func cell(i, n int) (int, int) {
+	start := i * n / imageSize
+	end := (i + 1) * n / imageSize
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
+
+// gray returns the luminance of the pixel at (x, y) in [0, 1], composited onto white.
+func gray(img image.Image, x, y int) float64 {
+	r, g, b, a := img.At(x, y).RGBA()
+	luminance := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 0xffff
+	return luminance + 1 - float64(a)/0xffff
+}
@@ -0,0 +1,73 @@
+package mnist
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRasterizeKeepsMNISTLayout(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 28, 28))
+	img.SetGray(3, 5, color.Gray{Y: 255})
+	img.SetGray(27, 27, color.Gray{Y: 51})
+
+	x := Rasterize(img)
+	rows, cols := x.Dims()
+	require.Equal(t, 1, rows)
+	require.Equal(t, 28*28, cols)
+
+	assert.InDelta(t, 1.0, x.At(0, 5*28+3), 1e-6)
+	assert.InDelta(t, 0.2, x.At(0, 27*28+27), 1e-6)
+	assert.Equal(t, 0.0, x.At(0, 0))
+}
+
+func TestRasterizeInvertsDarkOnLight(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 28, 28))
+	for y := 0; y < 28; y++ {
+		for x := 0; x < 28; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+	img.Set(10, 10, color.Black)
+
+	x := Rasterize(img)
+	assert.InDelta(t, 1.0, x.At(0, 10*28+10), 1e-6)
+	assert.InDelta(t, 0.0, x.At(0, 0), 1e-6)
+}
+
+func TestRasterizeTreatsTransparentAsWhite(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 28, 28))
+	img.Set(0, 0, color.NRGBA{A: 255})
+
+	x := Rasterize(img)
+	assert.InDelta(t, 1.0, x.At(0, 0), 1e-6)
+	assert.InDelta(t, 0.0, x.At(0, 1), 1e-6)
+}
+
+func TestReadPNGResamples(t *testing.T) {
+	// A 56x56 image whose left half is bright, so each cell averages a 2x2 block.
+	img := image.NewGray(image.Rect(0, 0, 56, 56))
+	for y := 0; y < 56; y++ {
+		for x := 0; x < 28; x++ {
+			img.SetGray(x, y, color.Gray{Y: 102})
+		}
+	}
+	// One odd pixel in the top right cell.
+	img.SetGray(55, 0, color.Gray{Y: 204})
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	x, err := ReadPNG(&buf)
+	require.NoError(t, err)
+
+	assert.InDelta(t, 0.4, x.At(0, 0), 1e-6)
+	assert.InDelta(t, 0.4, x.At(0, 27*28+13), 1e-6)
+	assert.InDelta(t, 0.2, x.At(0, 27), 1e-6)
+	assert.InDelta(t, 0.0, x.At(0, 27*28+27), 1e-6)
+}
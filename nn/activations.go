@@ -0,0 +1,361 @@
+package nn
+
+import (
+	"encoding/json"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	seluAlpha = 1.6732632423543772848170429916717
+	seluScale = 1.0507009873554804934193349852946
+)
+
+// elementwise is an activation that applies a function to each entry independently.
+type elementwise struct {
+	x *mat.Dense
+
+	f  func(float64) float64
+	df func(float64) float64
+}
+
+func (l *elementwise) SetTrainingEnabled(b bool) {
+	// Activations behave the same in training and inference.
+}
+
+func (l *elementwise) Forwards(x *mat.Dense) *mat.Dense {
+	l.x = x
+	return l.Infer(x)
+}
+
+// Infer returns the output of the layer without recording anything for Backwards.
+func (l *elementwise) Infer(x *mat.Dense) *mat.Dense {
+	rows, cols := x.Dims()
+	result := mat.NewDense(rows, cols, nil)
+	result.Apply(func(_, _ int, v float64) float64 {
+		return l.f(v)
+	}, x)
+
+	return result
+}
+
+func (l *elementwise) Backwards(grad *mat.Dense) *mat.Dense {
+	rows, cols := grad.Dims()
+	result := mat.NewDense(rows, cols, nil)
+	result.Apply(func(i, j int, g float64) float64 {
+		return g * l.df(l.x.At(i, j))
+	}, grad)
+
+	return result
+}
+
+func (l *elementwise) Weights() []*mat.Dense {
+	return make([]*mat.Dense, 0)
+}
+
+func (l *elementwise) Parameters() []*Parameter {
+	return make([]*Parameter, 0)
+}
+
+func (l *elementwise) MarshalLayer() (json.RawMessage, error) {
+	return nil, nil
+}
+
+// Sigmoid is the logistic function 1 / (1 + e^-x).
+type Sigmoid struct{ elementwise }
+
+func NewSigmoid() *Sigmoid {
+	return &Sigmoid{elementwise{
+		f: sigmoid,
+		df: func(x float64) float64 {
+			s := sigmoid(x)
+			return s * (1 - s)
+		},
+	}}
+}
+
+func (l *Sigmoid) LayerType() string {
+	return "sigmoid"
+}
+
+// Tanh is the hyperbolic tangent.
+type Tanh struct{ elementwise }
+
+func NewTanh() *Tanh {
+	return &Tanh{elementwise{
+		f: math.Tanh,
+		df: func(x float64) float64 {
+			t := math.Tanh(x)
+			return 1 - t*t
+		},
+	}}
+}
+
+func (l *Tanh) LayerType() string {
+	return "tanh"
+}
+
+// LeakyReLU is a ReLU that lets through alpha times its input when the input is negative.
+type LeakyReLU struct {
+	elementwise
+	alpha float64
+}
+
+func NewLeakyReLU(alpha float64) *LeakyReLU {
+	return &LeakyReLU{
+		alpha: alpha,
+		elementwise: elementwise{
+			f: func(x float64) float64 {
+				if x > 0 {
+					return x
+				}
+				return alpha * x
+			},
+			df: func(x float64) float64 {
+				if x > 0 {
+					return 1
+				}
+				return alpha
+			},
+		},
+	}
+}
+
+func (l *LeakyReLU) LayerType() string {
+	return "leaky_relu"
+}
+
+func (l *LeakyReLU) MarshalLayer() (json.RawMessage, error) {
+	return json.Marshal(alphaConfig{Alpha: l.alpha})
+}
+
+// ELU is the exponential linear unit, alpha * (e^x - 1) for negative x.
+type ELU struct {
+	elementwise
+	alpha float64
+}
+
+func NewELU(alpha float64) *ELU {
+	return &ELU{
+		alpha: alpha,
+		elementwise: elementwise{
+			f: func(x float64) float64 {
+				return elu(x, alpha)
+			},
+			df: func(x float64) float64 {
+				return eluDerivative(x, alpha)
+			},
+		},
+	}
+}
+
+func (l *ELU) LayerType() string {
+	return "elu"
+}
+
+func (l *ELU) MarshalLayer() (json.RawMessage, error) {
+	return json.Marshal(alphaConfig{Alpha: l.alpha})
+}
+
+// SELU is the self-normalizing scaled exponential linear unit.
+type SELU struct{ elementwise }
+
+func NewSELU() *SELU {
+	return &SELU{elementwise{
+		f: func(x float64) float64 {
+			return seluScale * elu(x, seluAlpha)
+		},
+		df: func(x float64) float64 {
+			return seluScale * eluDerivative(x, seluAlpha)
+		},
+	}}
+}
+
+func (l *SELU) LayerType() string {
+	return "selu"
+}
+
+// GELU is the Gaussian error linear unit x * Φ(x), where Φ is the standard normal CDF.
+type GELU struct{ elementwise }
+
+func NewGELU() *GELU {
+	return &GELU{elementwise{
+		f: func(x float64) float64 {
+			return x * normalCDF(x)
+		},
+		df: func(x float64) float64 {
+			return normalCDF(x) + x*math.Exp(-x*x/2)/math.Sqrt(2*math.Pi)
+		},
+	}}
+}
+
+func (l *GELU) LayerType() string {
+	return "gelu"
+}
+
+// Swish is x * sigmoid(x), also known as SiLU.
+type Swish struct{ elementwise }
+
+func NewSwish() *Swish {
+	return &Swish{elementwise{
+		f: func(x float64) float64 {
+			return x * sigmoid(x)
+		},
+		df: func(x float64) float64 {
+			s := sigmoid(x)
+			return s + x*s*(1-s)
+		},
+	}}
+}
+
+func (l *Swish) LayerType() string {
+	return "swish"
+}
+
+// Softplus is the smooth approximation to ReLU, log(1 + e^x).
+type Softplus struct{ elementwise }
+
+func NewSoftplus() *Softplus {
+	return &Softplus{elementwise{
+		f: func(x float64) float64 {
+			// Stable for large |x|.
+			return math.Max(x, 0) + math.Log1p(math.Exp(-math.Abs(x)))
+		},
+		df: sigmoid,
+	}}
+}
+
+func (l *Softplus) LayerType() string {
+	return "softplus"
+}
+
+// PReLU is a leaky ReLU where the slope for negative inputs is learned separately for each feature.
+type PReLU struct {
+	alpha *Parameter
+	x     *mat.Dense
+}
+
+// NewPReLU returns a PReLU for inputs with the given number of features,
+// with the slopes initialized to 0.25.
+func NewPReLU(dimension int) *PReLU {
+	return &PReLU{alpha: NewParameter(constantMatrix(1, dimension, 0.25))}
+}
+
+func (l *PReLU) SetTrainingEnabled(b bool) {
+	// PReLU behaves the same in training and inference.
+}
+
+func (l *PReLU) Forwards(x *mat.Dense) *mat.Dense {
+	l.x = x
+	return l.Infer(x)
+}
+
+// Infer returns the output of the layer without recording anything for Backwards.
+func (l *PReLU) Infer(x *mat.Dense) *mat.Dense {
+	rows, cols := x.Dims()
+	result := mat.NewDense(rows, cols, nil)
+	result.Apply(func(_, j int, v float64) float64 {
+		if v > 0 {
+			return v
+		}
+		return l.alpha.Value.At(0, j) * v
+	}, x)
+
+	return result
+}
+
+func (l *PReLU) Backwards(grad *mat.Dense) *mat.Dense {
+	rows, cols := grad.Dims()
+	result := mat.NewDense(rows, cols, nil)
+	alpha, alphaGrad := raw(l.alpha.Value), raw(l.alpha.Grad)
+
+	for r := 0; r < rows; r++ {
+		g, x, xGrad := grad.RawRowView(r), l.x.RawRowView(r), result.RawRowView(r)
+		for c := range g {
+			if x[c] > 0 {
+				xGrad[c] = g[c]
+			} else {
+				xGrad[c] = g[c] * alpha[c]
+				alphaGrad[c] += g[c] * x[c]
+			}
+		}
+	}
+
+	return result
+}
+
+func (l *PReLU) Weights() []*mat.Dense {
+	return make([]*mat.Dense, 0)
+}
+
+func (l *PReLU) Parameters() []*Parameter {
+	return []*Parameter{l.alpha}
+}
+
+func (l *PReLU) inputSize() int {
+	_, cols := l.alpha.Value.Dims()
+	return cols
+}
+
+func (l *PReLU) LayerType() string {
+	return "prelu"
+}
+
+func (l *PReLU) MarshalLayer() (json.RawMessage, error) {
+	_, cols := l.alpha.Value.Dims()
+	return json.Marshal(cols)
+}
+
+func decodePReLU(config json.RawMessage) (Value, error) {
+	var dimension int
+	if err := json.Unmarshal(config, &dimension); err != nil {
+		return nil, err
+	}
+	if err := checkDimension("dimension", dimension); err != nil {
+		return nil, err
+	}
+	return NewPReLU(dimension), nil
+}
+
+type alphaConfig struct {
+	Alpha float64 `json:"alpha"`
+}
+
+func decodeLeakyReLU(config json.RawMessage) (Value, error) {
+	var cfg alphaConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, err
+	}
+	return NewLeakyReLU(cfg.Alpha), nil
+}
+
+func decodeELU(config json.RawMessage) (Value, error) {
+	var cfg alphaConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, err
+	}
+	return NewELU(cfg.Alpha), nil
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+func elu(x, alpha float64) float64 {
+	if x > 0 {
+		return x
+	}
+	return alpha * math.Expm1(x)
+}
+
+func eluDerivative(x, alpha float64) float64 {
+	if x > 0 {
+		return 1
+	}
+	return alpha * math.Exp(x)
+}
+
+func normalCDF(x float64) float64 {
+	return (1 + math.Erf(x/math.Sqrt2)) / 2
+}
@@ -0,0 +1,156 @@
+package nn
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestActivationValues(t *testing.T) {
+	cases := []struct {
+		name     string
+		v        Value
+		x        float64
+		expected float64
+	}{
+		{"sigmoid", NewSigmoid(), 0, 0.5},
+		{"tanh", NewTanh(), 1, math.Tanh(1)},
+		{"leaky relu", NewLeakyReLU(0.1), -2, -0.2},
+		{"leaky relu positive", NewLeakyReLU(0.1), 2, 2},
+		{"elu", NewELU(1), -1, math.Exp(-1) - 1},
+		{"selu", NewSELU(), 1, seluScale},
+		{"gelu", NewGELU(), 1, 0.8413447460685429},
+		{"swish", NewSwish(), 1, 1 / (1 + math.Exp(-1))},
+		{"softplus", NewSoftplus(), 0, math.Log(2)},
+		{"softplus large", NewSoftplus(), 1000, 1000},
+		{"prelu", NewPReLU(1), -4, -1},
+	}
+
+	for _, c := range cases {
+		y := c.v.Forwards(mat.NewDense(1, 1, []float64{c.x}))
+		assert.InDelta(t, c.expected, y.At(0, 0), 1e-9, c.name)
+	}
+}
+
+func TestActivationsNumericGradient(t *testing.T) {
+	activations := map[string]Value{
+		"sigmoid":    NewSigmoid(),
+		"tanh":       NewTanh(),
+		"leaky relu": NewLeakyReLU(0.01),
+		"elu":        NewELU(1),
+		"selu":       NewSELU(),
+		"gelu":       NewGELU(),
+		"swish":      NewSwish(),
+		"softplus":   NewSoftplus(),
+		"prelu":      NewPReLU(4),
+	}
+
+	x := randomNonZeroMatrix(3, 4)
+	y := randomNonZeroMatrix(3, 4)
+
+	for name, v := range activations {
+		assert.NoError(t, SimpleGradientTest(v, x, y), name)
+	}
+}
+
+func TestPReLUAlphaGrad(t *testing.T) {
+	l := NewPReLU(4)
+	x := randomNonZeroMatrix(3, 4)
+	y := randomNonZeroMatrix(3, 4)
+
+	stub := &ValueStub{
+		ForwardsImpl: func(alpha *mat.Dense) *mat.Dense {
+			l.alpha.Value = alpha
+			return l.Forwards(x)
+		},
+		BackwardsImpl: func(grad *mat.Dense) *mat.Dense {
+			ZeroGradients(l.Parameters())
+			l.Backwards(grad)
+			return l.alpha.Grad
+		},
+	}
+
+	assert.NoError(t, SimpleGradientTest(stub, mat.NewDense(1, 4, []float64{0.1, 0.2, 0.3, -0.4}), y))
+}
+
+func TestDenseLayerIsLinear(t *testing.T) {
+	x := randomNonZeroMatrix(3, 4)
+	y := randomNonZeroMatrix(3, 2)
+
+	l := NewDenseLayer(4, 2)
+	assert.NoError(t, SimpleGradientTest(l, x, y))
+
+	// Unlike with a ReLU, negative outputs are passed through.
+	l.w.Value = mat.NewDense(4, 2, []float64{-1, -1, -1, -1, -1, -1, -1, -1})
+	l.b.Value = mat.NewDense(1, 2, []float64{-100, -100})
+	out := l.Forwards(x)
+	assert.True(t, out.At(0, 0) < 0)
+}
+
+func TestFullyConnectedWithActivation(t *testing.T) {
+	x := randomNonZeroMatrix(3, 4)
+	y := randomNonZeroMatrix(3, 2)
+
+	assert.NoError(t, SimpleGradientTest(NewFullyConnectedLayer(4, 2, WithActivation(NewTanh())), x, y))
+}
+
+func TestDenseLayerTrainsPReLUActivation(t *testing.T) {
+	prelu := NewPReLU(2)
+	l := NewDenseLayer(3, 2, WithActivation(prelu))
+
+	params := l.Parameters()
+	require.Len(t, params, 3)
+	assert.Equal(t, prelu.alpha, params[2])
+
+	x := randomNonZeroMatrix(8, 3)
+	y := mat.NewDense(8, 2, nil)
+	y.Scale(-0.5, l.Forwards(x))
+
+	opt := NewSGD(0.05)
+	alpha := mat.DenseCopyOf(prelu.alpha.Value)
+	first := 0.0
+	for i := 0; i < 100; i++ {
+		ZeroGradients(params)
+		loss, grad := L2Loss(y, l.Forwards(x))
+		if i == 0 {
+			first = loss
+		}
+		l.Backwards(grad)
+		opt.Update(params)
+	}
+
+	loss, _ := L2Loss(y, l.Forwards(x))
+	assert.True(t, loss < first)
+	assert.False(t, mat.Equal(alpha, prelu.alpha.Value))
+
+	// The trained alpha is saved along with the layer.
+	clone, err := Clone(l)
+	require.NoError(t, err)
+	assert.Equal(t, l.Forwards(x).RawMatrix().Data, clone.Forwards(x).RawMatrix().Data)
+}
+
+func TestActivationsSaveLoadRoundTrip(t *testing.T) {
+	prelu := NewPReLU(3)
+	prelu.alpha.Value.Set(0, 1, 0.7)
+
+	net := NewFeedForwardNetwork(
+		NewDenseLayer(4, 3, WithActivation(NewLeakyReLU(0.2))),
+		NewELU(0.5),
+		prelu,
+		NewDenseLayer(3, 3),
+		NewGELU(),
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, net.Save(&buf))
+
+	loaded, err := Load(&buf)
+	require.NoError(t, err)
+
+	x := randomNonZeroMatrix(2, 4)
+	assert.Equal(t, net.Forwards(x).RawMatrix().Data, loaded.Forwards(x).RawMatrix().Data)
+}
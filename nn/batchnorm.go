@@ -0,0 +1,216 @@
+package nn
+
+import (
+	"encoding/json"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	defaultNormMomentum = 0.1
+	defaultNormEpsilon  = 1e-5
+)
+
+// BatchNorm normalizes each feature (column) over the rows of a batch, then applies
+// a learned scale (gamma) and shift (beta).
+// When training is disabled, running estimates of the mean and variance collected
+// during training are used in place of the batch statistics.
+type BatchNorm struct {
+	gamma *Parameter
+	beta  *Parameter
+
+	runningMean *mat.Dense
+	runningVar  *mat.Dense
+	momentum    float64
+	epsilon     float64
+
+	xHat         *mat.Dense
+	invStd       []float64
+	trainingMode bool
+}
+
+func NewBatchNormLayer(dimension int) *BatchNorm {
+	return &BatchNorm{
+		gamma:        NewParameter(constantMatrix(1, dimension, 1)),
+		beta:         NewParameter(mat.NewDense(1, dimension, nil)),
+		runningMean:  mat.NewDense(1, dimension, nil),
+		runningVar:   constantMatrix(1, dimension, 1),
+		momentum:     defaultNormMomentum,
+		epsilon:      defaultNormEpsilon,
+		trainingMode: true,
+	}
+}
+
+func (l *BatchNorm) SetTrainingEnabled(b bool) {
+	l.trainingMode = b
+}
+
+func (l *BatchNorm) Forwards(x *mat.Dense) *mat.Dense {
+	rows, cols := x.Dims()
+	mean := raw(l.runningMean)
+	variance := raw(l.runningVar)
+
+	if l.trainingMode {
+		mean, variance = columnMeanVariance(x)
+		l.updateRunningStatistics(mean, variance, rows)
+	}
+
+	l.invStd = make([]float64, cols)
+	for c := range l.invStd {
+		l.invStd[c] = 1 / math.Sqrt(variance[c]+l.epsilon)
+	}
+
+	l.xHat = mat.NewDense(rows, cols, nil)
+	l.xHat.Apply(func(r, c int, v float64) float64 {
+		return (v - mean[c]) * l.invStd[c]
+	}, x)
+
+	result := mat.NewDense(rows, cols, nil)
+	result.Apply(func(r, c int, v float64) float64 {
+		return v*l.gamma.Value.At(0, c) + l.beta.Value.At(0, c)
+	}, l.xHat)
+
+	return result
+}
+
+// Infer returns the output of the layer with training disabled, normalizing using the
+// running statistics. Unlike Forwards, it does not record anything for Backwards.
+func (l *BatchNorm) Infer(x *mat.Dense) *mat.Dense {
+	rows, cols := x.Dims()
+	mean, variance := raw(l.runningMean), raw(l.runningVar)
+	gamma, beta := raw(l.gamma.Value), raw(l.beta.Value)
+
+	result := mat.NewDense(rows, cols, nil)
+	result.Apply(func(_, c int, v float64) float64 {
+		xHat := (v - mean[c]) * (1 / math.Sqrt(variance[c]+l.epsilon))
+		return xHat*gamma[c] + beta[c]
+	}, x)
+
+	return result
+}
+
+func (l *BatchNorm) Backwards(grad *mat.Dense) *mat.Dense {
+	rows, cols := grad.Dims()
+	result := mat.NewDense(rows, cols, nil)
+
+	for c := 0; c < cols; c++ {
+		gamma := l.gamma.Value.At(0, c)
+		var sumGrad, sumGradXHat float64
+		for r := 0; r < rows; r++ {
+			sumGrad += grad.At(r, c)
+			sumGradXHat += grad.At(r, c) * l.xHat.At(r, c)
+		}
+
+		l.beta.Grad.Set(0, c, l.beta.Grad.At(0, c)+sumGrad)
+		l.gamma.Grad.Set(0, c, l.gamma.Grad.At(0, c)+sumGradXHat)
+
+		n := float64(rows)
+		for r := 0; r < rows; r++ {
+			if l.trainingMode {
+				// The batch statistics depend on every row, which adds the two correction terms.
+				v := n*grad.At(r, c) - sumGrad - l.xHat.At(r, c)*sumGradXHat
+				result.Set(r, c, gamma*l.invStd[c]*v/n)
+			} else {
+				result.Set(r, c, gamma*l.invStd[c]*grad.At(r, c))
+			}
+		}
+	}
+
+	return result
+}
+
+func (l *BatchNorm) Weights() []*mat.Dense {
+	return make([]*mat.Dense, 0)
+}
+
+func (l *BatchNorm) Parameters() []*Parameter {
+	return []*Parameter{l.gamma, l.beta}
+}
+
+// State returns the running mean and variance.
+func (l *BatchNorm) State() []*mat.Dense {
+	return []*mat.Dense{l.runningMean, l.runningVar}
+}
+
+type batchNormConfig struct {
+	Dimension int     `json:"dimension"`
+	Momentum  float64 `json:"momentum"`
+	Epsilon   float64 `json:"epsilon"`
+}
+
+func (l *BatchNorm) inputSize() int {
+	_, cols := l.gamma.Value.Dims()
+	return cols
+}
+
+func (l *BatchNorm) LayerType() string {
+	return "batch_norm"
+}
+
+func (l *BatchNorm) MarshalLayer() (json.RawMessage, error) {
+	_, cols := l.gamma.Value.Dims()
+	return json.Marshal(batchNormConfig{
+		Dimension: cols,
+		Momentum:  l.momentum,
+		Epsilon:   l.epsilon,
+	})
+}
+
+func decodeBatchNorm(config json.RawMessage) (Value, error) {
+	var cfg batchNormConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, err
+	}
+	if err := checkDimension("dimension", cfg.Dimension); err != nil {
+		return nil, err
+	}
+
+	l := NewBatchNormLayer(cfg.Dimension)
+	l.momentum = cfg.Momentum
+	l.epsilon = cfg.Epsilon
+	return l, nil
+}
+
+func (l *BatchNorm) updateRunningStatistics(mean, variance []float64, rows int) {
+	// The running variance uses the unbiased estimate.
+	correction := 1.0
+	if rows > 1 {
+		correction = float64(rows) / float64(rows-1)
+	}
+
+	runningMean, runningVar := raw(l.runningMean), raw(l.runningVar)
+	for c := range runningMean {
+		runningMean[c] = (1-l.momentum)*runningMean[c] + l.momentum*mean[c]
+		runningVar[c] = (1-l.momentum)*runningVar[c] + l.momentum*variance[c]*correction
+	}
+}
+
+// columnMeanVariance returns the mean and (biased) variance of each column of x.
+func columnMeanVariance(x *mat.Dense) (mean, variance []float64) {
+	rows, cols := x.Dims()
+	mean = make([]float64, cols)
+	variance = make([]float64, cols)
+
+	for r := 0; r < rows; r++ {
+		for c, v := range x.RawRowView(r) {
+			mean[c] += v / float64(rows)
+		}
+	}
+
+	for r := 0; r < rows; r++ {
+		for c, v := range x.RawRowView(r) {
+			variance[c] += (v - mean[c]) * (v - mean[c]) / float64(rows)
+		}
+	}
+
+	return mean, variance
+}
+
+func constantMatrix(rows, cols int, v float64) *mat.Dense {
+	data := make([]float64, rows*cols)
+	for i := range data {
+		data[i] = v
+	}
+	return mat.NewDense(rows, cols, data)
+}
@@ -0,0 +1,94 @@
+package nn
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestBatchNormForwards(t *testing.T) {
+	l := NewBatchNormLayer(2)
+
+	x := mat.NewDense(4, 2, []float64{
+		1, 10,
+		2, 20,
+		3, 30,
+		4, 40,
+	})
+
+	y := l.Forwards(x)
+	mean, variance := columnMeanVariance(y)
+
+	for c := 0; c < 2; c++ {
+		assert.InDelta(t, 0, mean[c], 1e-9)
+		assert.InDelta(t, 1, variance[c], 1e-4)
+	}
+
+	// The running statistics move towards the batch statistics.
+	assert.InDelta(t, 0.25, l.runningMean.At(0, 0), 1e-9)
+	assert.InDelta(t, 0.9+0.1*5.0/3.0, l.runningVar.At(0, 0), 1e-9)
+}
+
+func TestBatchNormInferenceUsesRunningStatistics(t *testing.T) {
+	l := NewBatchNormLayer(1)
+	l.runningMean.Set(0, 0, 2)
+	l.runningVar.Set(0, 0, 4)
+	l.SetTrainingEnabled(false)
+
+	y := l.Forwards(mat.NewDense(2, 1, []float64{2, 6}))
+
+	assert.InDelta(t, 0, y.At(0, 0), 1e-6)
+	assert.InDelta(t, 2, y.At(1, 0), 1e-4)
+	assert.Equal(t, 2.0, l.runningMean.At(0, 0))
+}
+
+func TestBatchNormNumericGradient(t *testing.T) {
+	x := randomNonZeroMatrix(5, 3)
+	y := randomNonZeroMatrix(5, 3)
+
+	training := NewBatchNormLayer(3)
+	training.gamma.Value = mat.NewDense(1, 3, []float64{0.5, 2, -1})
+	assert.NoError(t, SimpleGradientTest(training, x, y))
+
+	inference := NewBatchNormLayer(3)
+	inference.runningMean = mat.NewDense(1, 3, []float64{0.1, -0.2, 0.3})
+	inference.SetTrainingEnabled(false)
+	assert.NoError(t, SimpleGradientTest(inference, x, y))
+}
+
+func TestBatchNormGammaGrad(t *testing.T) {
+	l := NewBatchNormLayer(3)
+	x := randomNonZeroMatrix(5, 3)
+	y := randomNonZeroMatrix(5, 3)
+
+	stub := &ValueStub{
+		ForwardsImpl: func(gamma *mat.Dense) *mat.Dense {
+			l.gamma.Value = gamma
+			return l.Forwards(x)
+		},
+		BackwardsImpl: func(grad *mat.Dense) *mat.Dense {
+			ZeroGradients(l.Parameters())
+			l.Backwards(grad)
+			return l.gamma.Grad
+		},
+	}
+
+	assert.NoError(t, SimpleGradientTest(stub, mat.NewDense(1, 3, []float64{0.5, 2, -1}), y))
+}
+
+func TestBatchNormSavesRunningStatistics(t *testing.T) {
+	l := NewBatchNormLayer(2)
+	l.Forwards(randomNonZeroMatrix(4, 2))
+
+	var buf bytes.Buffer
+	require.NoError(t, NewFeedForwardNetwork(l).Save(&buf))
+
+	loaded, err := Load(&buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, l.State()[0].RawMatrix().Data, loaded.State()[0].RawMatrix().Data)
+	assert.Equal(t, l.State()[1].RawMatrix().Data, loaded.State()[1].RawMatrix().Data)
+}
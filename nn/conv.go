@@ -0,0 +1,298 @@
+package nn
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ImageShape describes how each row of a matrix is laid out as an image volume.
+// Rows hold Channels planes of Height×Width pixels one after another, each plane
+// stored row-major, as produced by the mnist package.
+type ImageShape struct {
+	Channels int `json:"channels"`
+	Height   int `json:"height"`
+	Width    int `json:"width"`
+}
+
+// Size returns the number of values in an image of this shape.
+func (s ImageShape) Size() int {
+	return s.Channels * s.Height * s.Width
+}
+
+// Conv2D is a 2D convolution over images with the given input shape.
+// The output has one channel for each filter.
+type Conv2D struct {
+	input, output ImageShape
+	kernelSize    int
+	cfg           layerConfig
+
+	w *Parameter
+	b *Parameter
+
+	// index maps each entry of the unrolled (im2col) matrix of a single image
+	// to its position in the input row, or -1 where it falls in the padding.
+	index []int
+	cols  []*mat.Dense
+}
+
+func NewConv2DLayer(input ImageShape, filters, kernelSize int, settings ...LayerSetting) *Conv2D {
+	cfg := initLayerConfig(settings...)
+	if err := checkWindow(cfg); err != nil {
+		panic(err.Error())
+	}
+
+	output := ImageShape{
+		Channels: filters,
+		Height:   convOutputSize(input.Height, kernelSize, cfg),
+		Width:    convOutputSize(input.Width, kernelSize, cfg),
+	}
+	if output.Height <= 0 || output.Width <= 0 {
+		panic(fmt.Sprintf("kernel of size %d does not fit input of size %dx%d", kernelSize, input.Height, input.Width))
+	}
+
+	// Convolutions are usually followed by a ReLU, so default to He initialization.
+	if cfg.weightInit == nil {
+		cfg.weightInit = HeNormal()
+	}
+
+	patchSize := input.Channels * kernelSize * kernelSize
+	fanIn, fanOut := patchSize, filters*kernelSize*kernelSize
+
+	return &Conv2D{
+		input:      input,
+		output:     output,
+		kernelSize: kernelSize,
+		cfg:        cfg,
+		w:          NewParameter(newInitializedMatrix(cfg, cfg.weightInit, patchSize, filters, fanIn, fanOut)),
+		b:          NewParameter(newInitializedMatrix(cfg, cfg.biasInit, 1, filters, fanIn, fanOut)),
+		index:      im2colIndex(input, output, kernelSize, cfg),
+	}
+}
+
+// OutputShape returns the shape of the images produced by this layer.
+func (l *Conv2D) OutputShape() ImageShape {
+	return l.output
+}
+
+func (l *Conv2D) SetTrainingEnabled(b bool) {
+	// Convolutions behave the same in training and inference.
+}
+
+func (l *Conv2D) Forwards(x *mat.Dense) *mat.Dense {
+	result, cols := l.forwards(x)
+	l.cols = cols
+	return result
+}
+
+// Infer returns the output of the layer without recording anything for Backwards.
+func (l *Conv2D) Infer(x *mat.Dense) *mat.Dense {
+	result, _ := l.forwards(x)
+	return result
+}
+
+// forwards returns the output of the layer, along with the unrolled patches of each row.
+func (l *Conv2D) forwards(x *mat.Dense) (*mat.Dense, []*mat.Dense) {
+	rows, _ := x.Dims()
+	patches := l.output.Height * l.output.Width
+	result := mat.NewDense(rows, l.output.Size(), nil)
+	cols := make([]*mat.Dense, rows)
+
+	for r := 0; r < rows; r++ {
+		col := im2col(x.RawRowView(r), l.index, patches)
+		cols[r] = col
+
+		out := mat.NewDense(patches, l.output.Channels, nil)
+		out.Mul(col, l.w.Value)
+
+		// Transpose the patches×filters result into planar channel-first layout.
+		row := result.RawRowView(r)
+		for p := 0; p < patches; p++ {
+			for f := 0; f < l.output.Channels; f++ {
+				row[f*patches+p] = out.At(p, f) + l.b.Value.At(0, f)
+			}
+		}
+	}
+
+	return result, cols
+}
+
+func (l *Conv2D) Backwards(grad *mat.Dense) *mat.Dense {
+	rows, _ := grad.Dims()
+	patches := l.output.Height * l.output.Width
+	filters := l.output.Channels
+	result := mat.NewDense(rows, l.input.Size(), nil)
+
+	wGrad := mat.NewDense(l.input.Channels*l.kernelSize*l.kernelSize, filters, nil)
+	for r := 0; r < rows; r++ {
+		g := mat.NewDense(patches, filters, nil)
+		gradRow := grad.RawRowView(r)
+		for p := 0; p < patches; p++ {
+			for f := 0; f < filters; f++ {
+				v := gradRow[f*patches+p]
+				g.Set(p, f, v)
+				l.b.Grad.Set(0, f, l.b.Grad.At(0, f)+v)
+			}
+		}
+
+		wGrad.Mul(l.cols[r].T(), g)
+		l.w.Grad.Add(l.w.Grad, wGrad)
+
+		colGrad := mat.NewDense(patches, len(l.index)/patches, nil)
+		colGrad.Mul(g, l.w.Value.T())
+		col2im(colGrad, l.index, result.RawRowView(r))
+	}
+
+	return result
+}
+
+func (l *Conv2D) Weights() []*mat.Dense {
+	return []*mat.Dense{l.w.Value}
+}
+
+func (l *Conv2D) Parameters() []*Parameter {
+	return []*Parameter{l.w, l.b}
+}
+
+type conv2DConfig struct {
+	Input      ImageShape `json:"input"`
+	Filters    int        `json:"filters"`
+	KernelSize int        `json:"kernel_size"`
+	Stride     int        `json:"stride"`
+	Padding    int        `json:"padding"`
+	Dilation   int        `json:"dilation"`
+}
+
+// validate returns an error for the configurations NewConv2DLayer would panic on.
+func (c conv2DConfig) validate() error {
+	if err := checkImageShape(c.Input); err != nil {
+		return err
+	}
+	if err := checkDimension("filters", c.Filters); err != nil {
+		return err
+	}
+	if err := checkDimension("kernel size", c.KernelSize); err != nil {
+		return err
+	}
+
+	cfg := initLayerConfig(WithStride(c.Stride), WithPadding(c.Padding), WithDilation(c.Dilation))
+	if err := checkWindow(cfg); err != nil {
+		return err
+	}
+	if convOutputSize(c.Input.Height, c.KernelSize, cfg) <= 0 || convOutputSize(c.Input.Width, c.KernelSize, cfg) <= 0 {
+		return fmt.Errorf("kernel of size %d does not fit input of size %dx%d", c.KernelSize, c.Input.Height, c.Input.Width)
+	}
+	return nil
+}
+
+func (l *Conv2D) inputSize() int {
+	return l.input.Size()
+}
+
+func (l *Conv2D) LayerType() string {
+	return "conv2d"
+}
+
+func (l *Conv2D) MarshalLayer() (json.RawMessage, error) {
+	return json.Marshal(conv2DConfig{
+		Input:      l.input,
+		Filters:    l.output.Channels,
+		KernelSize: l.kernelSize,
+		Stride:     l.cfg.stride,
+		Padding:    l.cfg.padding,
+		Dilation:   l.cfg.dilation,
+	})
+}
+
+func decodeConv2D(config json.RawMessage) (Value, error) {
+	var cfg conv2DConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return NewConv2DLayer(
+		cfg.Input, cfg.Filters, cfg.KernelSize,
+		WithStride(cfg.Stride),
+		WithPadding(cfg.Padding),
+		WithDilation(cfg.Dilation),
+	), nil
+}
+
+// checkWindow returns an error if the stride, padding or dilation of a convolution
+// or pooling layer is out of range.
+func checkWindow(cfg layerConfig) error {
+	switch {
+	case cfg.stride < 1:
+		return fmt.Errorf("stride must be at least 1, got %d", cfg.stride)
+	case cfg.dilation < 1:
+		return fmt.Errorf("dilation must be at least 1, got %d", cfg.dilation)
+	case cfg.padding < 0:
+		return fmt.Errorf("padding must not be negative, got %d", cfg.padding)
+	}
+	return nil
+}
+
+// convOutputSize returns the number of windows that fit along a dimension of the input,
+// which is 0 if the (dilated) kernel is larger than the padded input.
+func convOutputSize(size, kernelSize int, cfg layerConfig) int {
+	span := size + 2*cfg.padding - cfg.dilation*(kernelSize-1) - 1
+	if span < 0 {
+		// Division truncates towards zero, so this would otherwise give 1.
+		return 0
+	}
+	return span/cfg.stride + 1
+}
+
+func im2colIndex(input, output ImageShape, kernelSize int, cfg layerConfig) []int {
+	patches := output.Height * output.Width
+	patchSize := input.Channels * kernelSize * kernelSize
+	index := make([]int, patches*patchSize)
+
+	for oy := 0; oy < output.Height; oy++ {
+		for ox := 0; ox < output.Width; ox++ {
+			p := oy*output.Width + ox
+			for c := 0; c < input.Channels; c++ {
+				for ky := 0; ky < kernelSize; ky++ {
+					for kx := 0; kx < kernelSize; kx++ {
+						y := oy*cfg.stride - cfg.padding + ky*cfg.dilation
+						x := ox*cfg.stride - cfg.padding + kx*cfg.dilation
+
+						i := p*patchSize + (c*kernelSize+ky)*kernelSize + kx
+						if y < 0 || y >= input.Height || x < 0 || x >= input.Width {
+							index[i] = -1
+						} else {
+							index[i] = (c*input.Height+y)*input.Width + x
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return index
+}
+
+// im2col unrolls the patches of a single image into the rows of a matrix.
+func im2col(x []float64, index []int, patches int) *mat.Dense {
+	data := make([]float64, len(index))
+	for i, j := range index {
+		if j >= 0 {
+			data[i] = x[j]
+		}
+	}
+	return mat.NewDense(patches, len(index)/patches, data)
+}
+
+// col2im adds the gradient of the unrolled matrix back into the gradient of the image.
+func col2im(col *mat.Dense, index []int, xGrad []float64) {
+	data := col.RawMatrix().Data
+	for i, j := range index {
+		if j >= 0 {
+			xGrad[j] += data[i]
+		}
+	}
+}
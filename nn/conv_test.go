@@ -0,0 +1,118 @@
+package nn
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestConv2DForwards(t *testing.T) {
+	l := NewConv2DLayer(ImageShape{Channels: 1, Height: 3, Width: 3}, 1, 2)
+	l.w.Value = mat.NewDense(4, 1, []float64{1, 0, 0, 1})
+	l.b.Value = mat.NewDense(1, 1, []float64{0.5})
+
+	x := mat.NewDense(1, 9, []float64{
+		1, 2, 3,
+		4, 5, 6,
+		7, 8, 9,
+	})
+
+	// Each output is the sum of the top-left and bottom-right of its window.
+	expected := []float64{
+		6.5, 8.5,
+		12.5, 14.5,
+	}
+
+	assert.Equal(t, ImageShape{Channels: 1, Height: 2, Width: 2}, l.OutputShape())
+	assert.Equal(t, expected, l.Forwards(x).RawRowView(0))
+}
+
+func TestConv2DOutputShape(t *testing.T) {
+	input := ImageShape{Channels: 3, Height: 28, Width: 28}
+
+	assert.Equal(t, ImageShape{8, 26, 26}, NewConv2DLayer(input, 8, 3).OutputShape())
+	assert.Equal(t, ImageShape{8, 28, 28}, NewConv2DLayer(input, 8, 3, WithPadding(1)).OutputShape())
+	assert.Equal(t, ImageShape{8, 14, 14}, NewConv2DLayer(input, 8, 3, WithPadding(1), WithStride(2)).OutputShape())
+	assert.Equal(t, ImageShape{8, 24, 24}, NewConv2DLayer(input, 8, 3, WithDilation(2)).OutputShape())
+}
+
+func TestConv2DRejectsInvalidWindows(t *testing.T) {
+	input := ImageShape{Channels: 1, Height: 4, Width: 4}
+	assert.Panics(t, func() { NewConv2DLayer(input, 1, 2, WithStride(0)) })
+	assert.Panics(t, func() { NewConv2DLayer(input, 1, 2, WithDilation(0)) })
+	assert.Panics(t, func() { NewConv2DLayer(input, 1, 2, WithPadding(-1)) })
+	assert.Panics(t, func() { NewMaxPool2DLayer(input, 2, WithStride(0)) })
+	assert.Panics(t, func() { NewConv2DLayer(input, 1, 5, WithStride(5)) })
+	assert.Panics(t, func() { NewMaxPool2DLayer(input, 5) })
+
+	for _, config := range []string{
+		`{"input": {"channels": 1, "height": 4, "width": 4}, "filters": 1, "kernel_size": 2, "stride": 0, "padding": 0, "dilation": 1}`,
+		`{"input": {"channels": 1, "height": 4, "width": 4}, "filters": 1, "kernel_size": 2, "stride": 1, "padding": 0, "dilation": 0}`,
+		`{"input": {"channels": 1, "height": 4, "width": 4}, "filters": 1, "kernel_size": 9, "stride": 1, "padding": 0, "dilation": 1}`,
+		`{"input": {"channels": 1, "height": 4, "width": 4}, "filters": 1, "kernel_size": 0, "stride": 1, "padding": 0, "dilation": 1}`,
+		`{"input": {"channels": 1, "height": 4, "width": 4}, "filters": 0, "kernel_size": 2, "stride": 1, "padding": 0, "dilation": 1}`,
+		`{"input": {"channels": 0, "height": 4, "width": 4}, "filters": 1, "kernel_size": 2, "stride": 1, "padding": 0, "dilation": 1}`,
+	} {
+		_, err := decodeConv2D([]byte(config))
+		assert.Error(t, err)
+	}
+
+	_, err := decodeAvgPool2D([]byte(`{"input": {"channels": 1, "height": 4, "width": 4}, "size": 2, "stride": 0, "padding": 0, "dilation": 1}`))
+	assert.Error(t, err)
+}
+
+func TestConv2DNumericGradient(t *testing.T) {
+	input := ImageShape{Channels: 2, Height: 5, Width: 4}
+	settings := map[string][]LayerSetting{
+		"default":  nil,
+		"stride":   {WithStride(2)},
+		"padding":  {WithPadding(1)},
+		"dilation": {WithDilation(2)},
+		"all":      {WithStride(2), WithPadding(2), WithDilation(2)},
+	}
+
+	for name, s := range settings {
+		l := NewConv2DLayer(input, 3, 2, s...)
+		x := randomNonZeroMatrix(2, input.Size())
+		y := randomNonZeroMatrix(2, l.OutputShape().Size())
+
+		assert.NoError(t, SimpleGradientTest(l, x, y), name)
+	}
+}
+
+func TestConv2DWGrad(t *testing.T) {
+	input := ImageShape{Channels: 2, Height: 4, Width: 4}
+	l := NewConv2DLayer(input, 3, 3, WithPadding(1), WithStride(2))
+	x := randomNonZeroMatrix(2, input.Size())
+	y := randomNonZeroMatrix(2, l.OutputShape().Size())
+
+	stub := &ValueStub{
+		ForwardsImpl: func(w *mat.Dense) *mat.Dense {
+			l.w.Value = w
+			return l.Forwards(x)
+		},
+		BackwardsImpl: func(grad *mat.Dense) *mat.Dense {
+			ZeroGradients(l.Parameters())
+			l.Backwards(grad)
+			return l.w.Grad
+		},
+	}
+
+	assert.NoError(t, SimpleGradientTest(stub, mat.DenseCopyOf(l.w.Value), y))
+}
+
+func randomNonZeroMatrix(rows, cols int) *mat.Dense {
+	r := rand.New(rand.NewSource(int64(rows*1000 + cols)))
+	result := mat.NewDense(rows, cols, nil)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			result.Set(i, j, r.Float64()*2+0.1)
+			if r.Intn(2) == 0 {
+				result.Set(i, j, -result.At(i, j))
+			}
+		}
+	}
+	return result
+}
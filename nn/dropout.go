@@ -1,6 +1,7 @@
 package nn
 
 import (
+	"encoding/json"
 	"math/rand"
 
 	"gonum.org/v1/gonum/mat"
@@ -10,10 +11,14 @@ type DropoutLayer struct {
 	mask         *mat.Dense
 	p            float64
 	trainingMode bool
+	rng          *rand.Rand
 }
 
-func NewDropoutLayer(p float64) *DropoutLayer {
-	return &DropoutLayer{p: p, trainingMode: true}
+// NewDropoutLayer returns a layer that drops each feature with probability p.
+// The only setting that applies is WithRand.
+func NewDropoutLayer(p float64, settings ...LayerSetting) *DropoutLayer {
+	cfg := initLayerConfig(settings...)
+	return &DropoutLayer{p: p, trainingMode: true, rng: cfg.rng}
 }
 
 func (l *DropoutLayer) SetTrainingEnabled(b bool) {
@@ -22,13 +27,11 @@ func (l *DropoutLayer) SetTrainingEnabled(b bool) {
 
 func (l *DropoutLayer) Forwards(x *mat.Dense) *mat.Dense {
 	if !l.trainingMode {
-		result := mat.DenseCopyOf(x)
-		result.Scale(1-l.p, x)
-		return result
+		return l.Infer(x)
 	}
 
 	rows, cols := x.Dims()
-	l.mask = newRandomMask(cols, l.p)
+	l.mask = newRandomMask(l.rng, cols, l.p)
 	// log.Printf("new mask: %v", l.mask)
 
 	result := mat.DenseCopyOf(x)
@@ -42,6 +45,14 @@ func (l *DropoutLayer) Forwards(x *mat.Dense) *mat.Dense {
 	return result
 }
 
+// Infer returns the output of the layer with training disabled, scaling the input by
+// the probability that each feature is kept.
+func (l *DropoutLayer) Infer(x *mat.Dense) *mat.Dense {
+	result := mat.DenseCopyOf(x)
+	result.Scale(1-l.p, x)
+	return result
+}
+
 func (l *DropoutLayer) Backwards(grad *mat.Dense) *mat.Dense {
 	rows, cols := grad.Dims()
 	result := mat.DenseCopyOf(grad)
@@ -55,15 +66,44 @@ func (l *DropoutLayer) Backwards(grad *mat.Dense) *mat.Dense {
 	return result
 }
 
+// Seed makes the layer draw its masks from a new generator seeded with seed.
+func (l *DropoutLayer) Seed(seed int64) {
+	l.rng = rand.New(rand.NewSource(seed))
+}
+
 func (l *DropoutLayer) Weights() []*mat.Dense {
 	return make([]*mat.Dense, 0)
 }
 
-func newRandomMask(size int, p float64) *mat.Dense {
+func (l *DropoutLayer) Parameters() []*Parameter {
+	return make([]*Parameter, 0)
+}
+
+type dropoutConfig struct {
+	P float64 `json:"p"`
+}
+
+func (l *DropoutLayer) LayerType() string {
+	return "dropout"
+}
+
+func (l *DropoutLayer) MarshalLayer() (json.RawMessage, error) {
+	return json.Marshal(dropoutConfig{P: l.p})
+}
+
+func decodeDropoutLayer(config json.RawMessage) (Value, error) {
+	var cfg dropoutConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, err
+	}
+	return NewDropoutLayer(cfg.P), nil
+}
+
+func newRandomMask(rng *rand.Rand, size int, p float64) *mat.Dense {
 	vals := make([]float64, size)
 
 	for i := range vals {
-		if rand.Float64() > p {
+		if rng.Float64() > p {
 			vals[i] = 1
 		}
 	}
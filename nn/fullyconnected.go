@@ -1,27 +1,47 @@
 package nn
 
 import (
+	"encoding/json"
+
 	"gonum.org/v1/gonum/mat"
 )
 
+// FullyConnectedLayer computes an affine function of its input, optionally followed by an activation.
 type FullyConnectedLayer struct {
-	// UpdateWeights controls whether or not the weights are updated on calling Backwards.
-	// Defaults to true.
+	// UpdateWeights controls whether or not gradients for the weights are accumulated
+	// on calling Backwards. Defaults to true.
 	UpdateWeights bool
 
-	w          *mat.Dense
-	b          *mat.Dense
+	w          *Parameter
+	b          *Parameter
 	x          *mat.Dense
 	activation Value
 
 	trainingEnabled bool
 }
 
-func NewFullyConnectedLayer(inputDimension, outputDimension int) *FullyConnectedLayer {
+// NewFullyConnectedLayer returns a fully connected layer followed by a ReLU activation.
+// The activation can be changed using WithActivation.
+func NewFullyConnectedLayer(inputDimension, outputDimension int, settings ...LayerSetting) *FullyConnectedLayer {
+	settings = append([]LayerSetting{WithActivation(NewRelu())}, settings...)
+	return NewDenseLayer(inputDimension, outputDimension, settings...)
+}
+
+// NewDenseLayer returns a fully connected layer with no activation, i.e. a purely linear layer.
+// This is what should be used for regression outputs, or before a SoftMax layer.
+func NewDenseLayer(inputDimension, outputDimension int, settings ...LayerSetting) *FullyConnectedLayer {
+	cfg := initLayerConfig(settings...)
+	if cfg.weightInit == nil {
+		cfg.weightInit = defaultInitializer(cfg.activation)
+	}
+
+	w := newInitializedMatrix(cfg, cfg.weightInit, inputDimension, outputDimension, inputDimension, outputDimension)
+	b := newInitializedMatrix(cfg, cfg.biasInit, 1, outputDimension, inputDimension, outputDimension)
+
 	l := &FullyConnectedLayer{
-		w:               NewRandomMatrix(inputDimension, outputDimension),
-		b:               NewRandomMatrix(1, outputDimension),
-		activation:      NewRelu(),
+		w:               NewParameter(w),
+		b:               NewParameter(b),
+		activation:      cfg.activation,
 		trainingEnabled: true,
 		UpdateWeights:   true,
 	}
@@ -31,24 +51,39 @@ func NewFullyConnectedLayer(inputDimension, outputDimension int) *FullyConnected
 
 func (l *FullyConnectedLayer) SetTrainingEnabled(b bool) {
 	l.trainingEnabled = b
+	if l.activation != nil {
+		l.activation.SetTrainingEnabled(b)
+	}
 }
 
 func (l *FullyConnectedLayer) Forwards(x *mat.Dense) *mat.Dense {
 	l.x = x
-	a := fullyConnectedForwards(x, l.w, l.b)
+	a := fullyConnectedForwards(x, l.w.Value, l.b.Value)
+	if l.activation == nil {
+		return a
+	}
 	return l.activation.Forwards(a)
 }
 
+// Infer returns the output of the layer without recording anything for Backwards.
+// The activation, if any, must implement Inferer.
+func (l *FullyConnectedLayer) Infer(x *mat.Dense) *mat.Dense {
+	a := fullyConnectedForwards(x, l.w.Value, l.b.Value)
+	if l.activation == nil {
+		return a
+	}
+	return l.activation.(Inferer).Infer(a)
+}
+
 func (l *FullyConnectedLayer) Backwards(grad *mat.Dense) *mat.Dense {
-	grad = l.activation.Backwards(grad)
-	result, deltaW, deltaB := fullyConnectedBackwards(grad, l.x, l.w, l.b)
+	if l.activation != nil {
+		grad = l.activation.Backwards(grad)
+	}
+	result, deltaW, deltaB := fullyConnectedBackwards(grad, l.x, l.w.Value, l.b.Value)
 
 	if l.UpdateWeights {
-		deltaW.Scale(-LearningRate, deltaW)
-		deltaB.Scale(-LearningRate, deltaB)
-
-		l.w.Add(l.w, deltaW)
-		l.b.Add(l.b, deltaB)
+		l.w.Grad.Add(l.w.Grad, deltaW)
+		l.b.Grad.Add(l.b.Grad, deltaB)
 	}
 
 	return result
@@ -57,7 +92,85 @@ func (l *FullyConnectedLayer) Backwards(grad *mat.Dense) *mat.Dense {
 func (l *FullyConnectedLayer) Weights() []*mat.Dense {
 	// Note(Ross): this weights slice is used for regularization.
 	// going wisdom is that the bias term doesn't need to be included.
-	return []*mat.Dense{l.w}
+	return []*mat.Dense{l.w.Value}
+}
+
+// Parameters returns the weights and bias, followed by the parameters of the activation, if any.
+func (l *FullyConnectedLayer) Parameters() []*Parameter {
+	params := []*Parameter{l.w, l.b}
+	if l.activation != nil {
+		params = append(params, l.activation.Parameters()...)
+	}
+	return params
+}
+
+// State returns the state of the activation, if it is Stateful.
+func (l *FullyConnectedLayer) State() []*mat.Dense {
+	if l.activation == nil {
+		return nil
+	}
+	return StateOf(l.activation)
+}
+
+type fullyConnectedConfig struct {
+	Input         int        `json:"input"`
+	Output        int        `json:"output"`
+	Activation    *layerSpec `json:"activation,omitempty"`
+	UpdateWeights bool       `json:"update_weights"`
+}
+
+func (l *FullyConnectedLayer) inputSize() int {
+	rows, _ := l.w.Value.Dims()
+	return rows
+}
+
+func (l *FullyConnectedLayer) LayerType() string {
+	return "fully_connected"
+}
+
+func (l *FullyConnectedLayer) MarshalLayer() (json.RawMessage, error) {
+	rows, cols := l.w.Value.Dims()
+	cfg := fullyConnectedConfig{
+		Input:         rows,
+		Output:        cols,
+		UpdateWeights: l.UpdateWeights,
+	}
+
+	if l.activation != nil {
+		activation, err := encodeLayer(l.activation)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Activation = &activation
+	}
+
+	return json.Marshal(cfg)
+}
+
+func decodeFullyConnectedLayer(config json.RawMessage) (Value, error) {
+	var cfg fullyConnectedConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, err
+	}
+	if err := checkDimension("input", cfg.Input); err != nil {
+		return nil, err
+	}
+	if err := checkDimension("output", cfg.Output); err != nil {
+		return nil, err
+	}
+
+	l := NewDenseLayer(cfg.Input, cfg.Output)
+	l.UpdateWeights = cfg.UpdateWeights
+
+	if cfg.Activation != nil {
+		activation, err := decodeLayer(*cfg.Activation)
+		if err != nil {
+			return nil, err
+		}
+		l.activation = activation
+	}
+
+	return l, nil
 }
 
 func fullyConnectedForwards(x, w, b *mat.Dense) *mat.Dense {
@@ -117,6 +117,12 @@ func (*ValueStub) Weights() []*mat.Dense {
 	return make([]*mat.Dense, 0)
 }
 
+func (*ValueStub) Parameters() []*Parameter {
+	return make([]*Parameter, 0)
+}
+
+func (*ValueStub) SetTrainingEnabled(bool) {}
+
 func (v *ValueStub) Forwards(x *mat.Dense) *mat.Dense {
 	return v.ForwardsImpl(x)
 }
@@ -0,0 +1,143 @@
+package nn
+
+import (
+	"math"
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Initializer sets the initial values of a layer's weights, drawing any random values from rng.
+// fanIn and fanOut are the number of inputs and outputs that each weight connects.
+type Initializer interface {
+	Initialize(w *mat.Dense, fanIn, fanOut int, rng *rand.Rand)
+}
+
+// InitializerFunc adapts an ordinary function to the Initializer interface.
+type InitializerFunc func(w *mat.Dense, fanIn, fanOut int, rng *rand.Rand)
+
+func (f InitializerFunc) Initialize(w *mat.Dense, fanIn, fanOut int, rng *rand.Rand) {
+	f(w, fanIn, fanOut, rng)
+}
+
+// XavierUniform (or Glorot uniform) draws weights from U(-a, a) with a = sqrt(6 / (fanIn + fanOut)).
+// It suits linear, sigmoid and tanh layers.
+func XavierUniform() Initializer {
+	return InitializerFunc(func(w *mat.Dense, fanIn, fanOut int, rng *rand.Rand) {
+		fillUniform(rng, w, math.Sqrt(6/float64(fanIn+fanOut)))
+	})
+}
+
+// XavierNormal (or Glorot normal) draws weights from N(0, 2 / (fanIn + fanOut)).
+func XavierNormal() Initializer {
+	return InitializerFunc(func(w *mat.Dense, fanIn, fanOut int, rng *rand.Rand) {
+		fillNormal(rng, w, math.Sqrt(2/float64(fanIn+fanOut)))
+	})
+}
+
+// HeUniform (or Kaiming uniform) draws weights from U(-a, a) with a = sqrt(6 / fanIn).
+// It suits layers followed by a ReLU.
+func HeUniform() Initializer {
+	return InitializerFunc(func(w *mat.Dense, fanIn, fanOut int, rng *rand.Rand) {
+		fillUniform(rng, w, math.Sqrt(6/float64(fanIn)))
+	})
+}
+
+// HeNormal (or Kaiming normal) draws weights from N(0, 2 / fanIn).
+func HeNormal() Initializer {
+	return InitializerFunc(func(w *mat.Dense, fanIn, fanOut int, rng *rand.Rand) {
+		fillNormal(rng, w, math.Sqrt(2/float64(fanIn)))
+	})
+}
+
+// LeCunUniform draws weights from U(-a, a) with a = sqrt(3 / fanIn).
+func LeCunUniform() Initializer {
+	return InitializerFunc(func(w *mat.Dense, fanIn, fanOut int, rng *rand.Rand) {
+		fillUniform(rng, w, math.Sqrt(3/float64(fanIn)))
+	})
+}
+
+// LeCunNormal draws weights from N(0, 1 / fanIn). It suits layers followed by a SELU.
+func LeCunNormal() Initializer {
+	return InitializerFunc(func(w *mat.Dense, fanIn, fanOut int, rng *rand.Rand) {
+		fillNormal(rng, w, math.Sqrt(1/float64(fanIn)))
+	})
+}
+
+// Orthogonal initializes the weights to a random orthogonal matrix scaled by gain.
+// If the matrix is not square, its rows or columns (whichever are fewer) are orthonormal.
+func Orthogonal(gain float64) Initializer {
+	return InitializerFunc(func(w *mat.Dense, fanIn, fanOut int, rng *rand.Rand) {
+		rows, cols := w.Dims()
+		if rows < cols {
+			t := mat.NewDense(cols, rows, nil)
+			Orthogonal(gain).Initialize(t, fanOut, fanIn, rng)
+			w.Copy(t.T())
+			return
+		}
+
+		a := mat.NewDense(rows, cols, nil)
+		fillNormal(rng, a, 1)
+
+		var qr mat.QR
+		qr.Factorize(a)
+		q := qr.QTo(nil)
+		r := qr.RTo(nil)
+
+		// Fix the signs using the diagonal of R, so that the result is uniformly distributed.
+		for j := 0; j < cols; j++ {
+			sign := gain
+			if r.At(j, j) < 0 {
+				sign = -gain
+			}
+			for i := 0; i < rows; i++ {
+				w.Set(i, j, q.At(i, j)*sign)
+			}
+		}
+	})
+}
+
+// Zeros sets every weight to zero. This is the default for biases.
+func Zeros() Initializer {
+	return Constant(0)
+}
+
+// Constant sets every weight to v.
+func Constant(v float64) Initializer {
+	return InitializerFunc(func(w *mat.Dense, fanIn, fanOut int, rng *rand.Rand) {
+		w.Apply(func(_, _ int, _ float64) float64 {
+			return v
+		}, w)
+	})
+}
+
+// defaultInitializer picks the weight initializer best suited to the given activation.
+func defaultInitializer(activation Value) Initializer {
+	switch activation.(type) {
+	case *Relu, *LeakyReLU, *PReLU, *ELU:
+		return HeNormal()
+	case *SELU:
+		return LeCunNormal()
+	default:
+		return XavierUniform()
+	}
+}
+
+// newInitializedMatrix returns a rows×cols matrix filled using init.
+func newInitializedMatrix(cfg layerConfig, init Initializer, rows, cols, fanIn, fanOut int) *mat.Dense {
+	result := mat.NewDense(rows, cols, nil)
+	init.Initialize(result, fanIn, fanOut, cfg.rng)
+	return result
+}
+
+func fillUniform(rng *rand.Rand, w *mat.Dense, limit float64) {
+	w.Apply(func(_, _ int, _ float64) float64 {
+		return (rng.Float64()*2 - 1) * limit
+	}, w)
+}
+
+func fillNormal(rng *rand.Rand, w *mat.Dense, stddev float64) {
+	w.Apply(func(_, _ int, _ float64) float64 {
+		return rng.NormFloat64() * stddev
+	}, w)
+}
@@ -0,0 +1,121 @@
+package nn
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestInitializerScales(t *testing.T) {
+	cases := []struct {
+		name    string
+		init    Initializer
+		uniform bool
+		scale   float64
+	}{
+		{"xavier uniform", XavierUniform(), true, math.Sqrt(6.0 / 300)},
+		{"xavier normal", XavierNormal(), false, math.Sqrt(2.0 / 300)},
+		{"he uniform", HeUniform(), true, math.Sqrt(6.0 / 200)},
+		{"he normal", HeNormal(), false, math.Sqrt(2.0 / 200)},
+		{"lecun uniform", LeCunUniform(), true, math.Sqrt(3.0 / 200)},
+		{"lecun normal", LeCunNormal(), false, math.Sqrt(1.0 / 200)},
+	}
+
+	for _, c := range cases {
+		w := newInitializedMatrix(initLayerConfig(), c.init, 200, 100, 200, 100)
+		mean, variance := rowMeanVariance(w.RawMatrix().Data)
+		assert.InDelta(t, 0, mean, c.scale/10, c.name)
+
+		if c.uniform {
+			for _, v := range w.RawMatrix().Data {
+				assert.True(t, math.Abs(v) <= c.scale, c.name)
+			}
+			// The variance of U(-a, a) is a^2 / 3.
+			assert.InDelta(t, c.scale*c.scale/3, variance, c.scale*c.scale/30, c.name)
+		} else {
+			assert.InDelta(t, c.scale*c.scale, variance, c.scale*c.scale/10, c.name)
+		}
+	}
+}
+
+func TestOrthogonal(t *testing.T) {
+	for _, shape := range [][2]int{{6, 4}, {4, 6}, {5, 5}} {
+		rows, cols := shape[0], shape[1]
+		w := newInitializedMatrix(initLayerConfig(), Orthogonal(2), rows, cols, rows, cols)
+
+		// Whichever of the rows or columns are fewer are orthogonal with norm 2.
+		var product mat.Dense
+		if rows >= cols {
+			product.Mul(w.T(), w)
+		} else {
+			product.Mul(w, w.T())
+		}
+
+		n, _ := product.Dims()
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				expected := 0.0
+				if i == j {
+					expected = 4
+				}
+				assert.InDelta(t, expected, product.At(i, j), 1e-9)
+			}
+		}
+	}
+}
+
+func TestConstantInitializers(t *testing.T) {
+	w := newInitializedMatrix(initLayerConfig(), Constant(0.5), 2, 3, 2, 3)
+	assert.Equal(t, []float64{0.5, 0.5, 0.5, 0.5, 0.5, 0.5}, w.RawMatrix().Data)
+
+	l := NewDenseLayer(3, 2, WithWeightInitializer(Zeros()), WithBiasInitializer(Constant(1)))
+	assert.Equal(t, 0.0, norm(l.w.Value))
+	assert.Equal(t, []float64{1, 1}, l.b.Value.RawMatrix().Data)
+}
+
+func TestDefaultInitializer(t *testing.T) {
+	// A ReLU layer uses He initialization, a linear one Xavier.
+	_, relu := rowMeanVariance(NewFullyConnectedLayer(400, 100).w.Value.RawMatrix().Data)
+	assert.InDelta(t, 2.0/400, relu, 0.0005)
+
+	_, linear := rowMeanVariance(NewDenseLayer(400, 100).w.Value.RawMatrix().Data)
+	assert.InDelta(t, 2.0/500, linear, 0.0005)
+
+	// Biases start at zero.
+	l := NewFullyConnectedLayer(3, 2)
+	assert.Equal(t, 0.0, norm(l.b.Value))
+}
+
+func TestWithRandIsReproducible(t *testing.T) {
+	build := func() *FeedForwardNetwork {
+		rng := rand.New(rand.NewSource(7))
+		return NewFeedForwardNetwork(
+			NewFullyConnectedLayer(3, 4, WithRand(rng)),
+			NewConv2DLayer(ImageShape{1, 2, 2}, 2, 2, WithRand(rng)),
+			NewDropoutLayer(0.5, WithRand(rng)),
+		)
+	}
+
+	a, b := build(), build()
+	for i, p := range a.Parameters() {
+		assert.Equal(t, p.Value.RawMatrix().Data, b.Parameters()[i].Value.RawMatrix().Data)
+	}
+
+	x := mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6})
+	assert.Equal(t, a.Forwards(x).RawMatrix().Data, b.Forwards(x).RawMatrix().Data)
+}
+
+func TestSeedMakesDropoutReproducible(t *testing.T) {
+	a := NewFeedForwardNetwork(NewDropoutLayer(0.5), NewDropoutLayer(0.5))
+	b := NewFeedForwardNetwork(NewDropoutLayer(0.5), NewDropoutLayer(0.5))
+	a.Seed(3)
+	b.Seed(3)
+
+	x := NewRandomMatrix(4, 64)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, a.Forwards(x).RawMatrix().Data, b.Forwards(x).RawMatrix().Data)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"gonum.org/v1/gonum/mat"
 )
 
+// LearningRate is the default learning rate used when training.
 const LearningRate = 0.2
 
 // Value represents any node in an NN computation graph.
@@ -17,6 +18,65 @@ type Value interface {
 	// Weights returns a slice of all variables that are updated during training.
 	// This makes it possible to implement regularization.
 	Weights() []*mat.Dense
+
+	// Parameters returns all learnable parameters of this node, together with
+	// the gradients accumulated for them by Backwards.
+	Parameters() []*Parameter
 }
 
 type Loss func(yHat *mat.Dense, y *mat.Dense) (loss float64, grad *mat.Dense)
+
+// Inferer is implemented by nodes that can compute their output for inference without
+// modifying themselves, so that it is safe to call Infer from many goroutines at once.
+// The result is the same as that of Forwards with training disabled.
+type Inferer interface {
+	Infer(X *mat.Dense) *mat.Dense
+}
+
+// Stateful is implemented by nodes that hold state besides their parameters which
+// is needed to make predictions, such as running statistics. The state is saved
+// along with the parameters.
+type Stateful interface {
+	State() []*mat.Dense
+}
+
+// Seeder is implemented by nodes that draw random numbers while training, such as dropout.
+// Seed replaces their source of randomness with one seeded by seed, so that the numbers
+// they draw from then on can be reproduced.
+type Seeder interface {
+	Seed(seed int64)
+}
+
+// StateOf returns the state of v if it is Stateful, or nil otherwise.
+func StateOf(v Value) []*mat.Dense {
+	if s, ok := v.(Stateful); ok {
+		return s.State()
+	}
+	return nil
+}
+
+// Parameter is a learnable matrix along with the gradient of the loss with respect to it.
+// Calls to Backwards add to Grad; it is up to the caller to zero it between updates.
+type Parameter struct {
+	Value *mat.Dense
+	Grad  *mat.Dense
+}
+
+// NewParameter wraps the given matrix as a parameter with a zero gradient.
+func NewParameter(value *mat.Dense) *Parameter {
+	rows, cols := value.Dims()
+	return &Parameter{
+		Value: value,
+		Grad:  mat.NewDense(rows, cols, nil),
+	}
+}
+
+// ZeroGradients resets the accumulated gradient of each of the given parameters.
+func ZeroGradients(params []*Parameter) {
+	for _, p := range params {
+		g := raw(p.Grad)
+		for i := range g {
+			g[i] = 0
+		}
+	}
+}
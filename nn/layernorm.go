@@ -0,0 +1,147 @@
+package nn
+
+import (
+	"encoding/json"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// LayerNorm normalizes each row across its features, then applies a learned
+// scale (gamma) and shift (beta) per feature.
+// It does not depend on the other rows in a batch, so it behaves the same in
+// training and inference, and works with a batch of a single row.
+type LayerNorm struct {
+	gamma   *Parameter
+	beta    *Parameter
+	epsilon float64
+
+	xHat   *mat.Dense
+	invStd []float64
+}
+
+func NewLayerNormLayer(dimension int) *LayerNorm {
+	return &LayerNorm{
+		gamma:   NewParameter(constantMatrix(1, dimension, 1)),
+		beta:    NewParameter(mat.NewDense(1, dimension, nil)),
+		epsilon: defaultNormEpsilon,
+	}
+}
+
+func (l *LayerNorm) SetTrainingEnabled(b bool) {
+	// LayerNorm behaves the same in training and inference.
+}
+
+func (l *LayerNorm) Forwards(x *mat.Dense) *mat.Dense {
+	result, xHat, invStd := l.forwards(x)
+	l.xHat, l.invStd = xHat, invStd
+	return result
+}
+
+// Infer returns the output of the layer without recording anything for Backwards.
+func (l *LayerNorm) Infer(x *mat.Dense) *mat.Dense {
+	result, _, _ := l.forwards(x)
+	return result
+}
+
+func (l *LayerNorm) forwards(x *mat.Dense) (result, xHat *mat.Dense, invStd []float64) {
+	rows, cols := x.Dims()
+	xHat = mat.NewDense(rows, cols, nil)
+	invStd = make([]float64, rows)
+	result = mat.NewDense(rows, cols, nil)
+
+	for r := 0; r < rows; r++ {
+		mean, variance := rowMeanVariance(x.RawRowView(r))
+		invStd[r] = 1 / math.Sqrt(variance+l.epsilon)
+
+		xHatRow, out := xHat.RawRowView(r), result.RawRowView(r)
+		for c, v := range x.RawRowView(r) {
+			xHatRow[c] = (v - mean) * invStd[r]
+			out[c] = xHatRow[c]*l.gamma.Value.At(0, c) + l.beta.Value.At(0, c)
+		}
+	}
+
+	return result, xHat, invStd
+}
+
+func (l *LayerNorm) Backwards(grad *mat.Dense) *mat.Dense {
+	rows, cols := grad.Dims()
+	result := mat.NewDense(rows, cols, nil)
+	gamma, gammaGrad, betaGrad := raw(l.gamma.Value), raw(l.gamma.Grad), raw(l.beta.Grad)
+
+	for r := 0; r < rows; r++ {
+		g, xHat := grad.RawRowView(r), l.xHat.RawRowView(r)
+
+		var sum, sumXHat float64
+		for c := range g {
+			gammaGrad[c] += g[c] * xHat[c]
+			betaGrad[c] += g[c]
+
+			dxHat := g[c] * gamma[c]
+			sum += dxHat
+			sumXHat += dxHat * xHat[c]
+		}
+
+		n := float64(cols)
+		xGrad := result.RawRowView(r)
+		for c := range g {
+			dxHat := g[c] * gamma[c]
+			xGrad[c] = l.invStd[r] * (n*dxHat - sum - xHat[c]*sumXHat) / n
+		}
+	}
+
+	return result
+}
+
+func (l *LayerNorm) Weights() []*mat.Dense {
+	return make([]*mat.Dense, 0)
+}
+
+func (l *LayerNorm) Parameters() []*Parameter {
+	return []*Parameter{l.gamma, l.beta}
+}
+
+type layerNormConfig struct {
+	Dimension int     `json:"dimension"`
+	Epsilon   float64 `json:"epsilon"`
+}
+
+func (l *LayerNorm) inputSize() int {
+	_, cols := l.gamma.Value.Dims()
+	return cols
+}
+
+func (l *LayerNorm) LayerType() string {
+	return "layer_norm"
+}
+
+func (l *LayerNorm) MarshalLayer() (json.RawMessage, error) {
+	_, cols := l.gamma.Value.Dims()
+	return json.Marshal(layerNormConfig{Dimension: cols, Epsilon: l.epsilon})
+}
+
+func decodeLayerNorm(config json.RawMessage) (Value, error) {
+	var cfg layerNormConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, err
+	}
+	if err := checkDimension("dimension", cfg.Dimension); err != nil {
+		return nil, err
+	}
+
+	l := NewLayerNormLayer(cfg.Dimension)
+	l.epsilon = cfg.Epsilon
+	return l, nil
+}
+
+// rowMeanVariance returns the mean and (biased) variance of the values in x.
+func rowMeanVariance(x []float64) (mean, variance float64) {
+	n := float64(len(x))
+	for _, v := range x {
+		mean += v / n
+	}
+	for _, v := range x {
+		variance += (v - mean) * (v - mean) / n
+	}
+	return mean, variance
+}
@@ -0,0 +1,58 @@
+package nn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLayerNormForwards(t *testing.T) {
+	l := NewLayerNormLayer(4)
+
+	x := mat.NewDense(2, 4, []float64{
+		1, 2, 3, 4,
+		-10, 0, 10, 20,
+	})
+
+	y := l.Forwards(x)
+	for r := 0; r < 2; r++ {
+		mean, variance := rowMeanVariance(y.RawRowView(r))
+		assert.InDelta(t, 0, mean, 1e-9)
+		assert.InDelta(t, 1, variance, 1e-4)
+	}
+
+	// Each row is normalized independently of the rest of the batch.
+	single := l.Forwards(mat.NewDense(1, 4, x.RawRowView(1)))
+	assert.Equal(t, y.RawRowView(1), single.RawRowView(0))
+}
+
+func TestLayerNormNumericGradient(t *testing.T) {
+	l := NewLayerNormLayer(4)
+	l.gamma.Value = mat.NewDense(1, 4, []float64{0.5, 2, -1, 1.5})
+
+	x := randomNonZeroMatrix(3, 4)
+	y := randomNonZeroMatrix(3, 4)
+
+	assert.NoError(t, SimpleGradientTest(l, x, y))
+}
+
+func TestLayerNormGammaGrad(t *testing.T) {
+	l := NewLayerNormLayer(4)
+	x := randomNonZeroMatrix(3, 4)
+	y := randomNonZeroMatrix(3, 4)
+
+	stub := &ValueStub{
+		ForwardsImpl: func(gamma *mat.Dense) *mat.Dense {
+			l.gamma.Value = gamma
+			return l.Forwards(x)
+		},
+		BackwardsImpl: func(grad *mat.Dense) *mat.Dense {
+			ZeroGradients(l.Parameters())
+			l.Backwards(grad)
+			return l.gamma.Grad
+		},
+	}
+
+	assert.NoError(t, SimpleGradientTest(stub, mat.NewDense(1, 4, []float64{0.5, 2, -1, 1.5}), y))
+}
@@ -1,6 +1,7 @@
 package nn
 
 import (
+	"fmt"
 	"math"
 
 	"gonum.org/v1/gonum/mat"
@@ -29,3 +30,143 @@ func l2(x, y *mat.Dense) float64 {
 
 	return l / 2
 }
+
+// crossEntropyEpsilon bounds predicted probabilities away from zero before taking logs.
+const crossEntropyEpsilon = 1e-12
+
+// CrossEntropySetting configures the cross entropy losses.
+type CrossEntropySetting func(*crossEntropyConfig)
+
+type crossEntropyConfig struct {
+	labelSmoothing float64
+	classWeights   []float64
+}
+
+// WithLabelSmoothing mixes the one-hot targets with a uniform distribution,
+// so that each target becomes (1-eps)*y + eps/classes.
+func WithLabelSmoothing(eps float64) CrossEntropySetting {
+	return func(c *crossEntropyConfig) {
+		c.labelSmoothing = eps
+	}
+}
+
+// WithClassWeights scales the loss of each row by the weight of its target class.
+// There must be one weight for each class, i.e. for each column of the targets;
+// the loss panics with a message saying so otherwise.
+func WithClassWeights(weights []float64) CrossEntropySetting {
+	return func(c *crossEntropyConfig) {
+		c.classWeights = weights
+	}
+}
+
+// CrossEntropyLoss is the categorical cross entropy between one-hot targets y
+// and predicted class probabilities yHat, e.g. the output of a SoftMax layer.
+func CrossEntropyLoss(y *mat.Dense, yHat *mat.Dense) (float64, *mat.Dense) {
+	return NewCrossEntropyLoss()(y, yHat)
+}
+
+// NewCrossEntropyLoss returns a cross entropy loss with the given settings.
+func NewCrossEntropyLoss(settings ...CrossEntropySetting) Loss {
+	cfg := initCrossEntropyConfig(settings...)
+
+	return func(y *mat.Dense, yHat *mat.Dense) (float64, *mat.Dense) {
+		rows, cols := yHat.Dims()
+		cfg.checkClasses(cols)
+		grad := mat.NewDense(rows, cols, nil)
+
+		var l float64
+		for r := 0; r < rows; r++ {
+			t := cfg.targets(y.RawRowView(r))
+			w := cfg.weight(y.RawRowView(r))
+			for c := 0; c < cols; c++ {
+				p := math.Max(yHat.At(r, c), crossEntropyEpsilon)
+				l -= w * t[c] * math.Log(p)
+				grad.Set(r, c, -w*t[c]/p/float64(rows))
+			}
+		}
+
+		return l / float64(rows), grad
+	}
+}
+
+// SoftmaxCrossEntropyLoss fuses a softmax with the cross entropy loss.
+// It expects yHat to be unnormalized logits, so the network should not end in a SoftMax layer.
+// The gradient is the numerically stable softmax(yHat) - y, avoiding the softmax Jacobian.
+func SoftmaxCrossEntropyLoss(y *mat.Dense, yHat *mat.Dense) (float64, *mat.Dense) {
+	return NewSoftmaxCrossEntropyLoss()(y, yHat)
+}
+
+// NewSoftmaxCrossEntropyLoss returns a fused softmax cross entropy loss with the given settings.
+func NewSoftmaxCrossEntropyLoss(settings ...CrossEntropySetting) Loss {
+	cfg := initCrossEntropyConfig(settings...)
+
+	return func(y *mat.Dense, yHat *mat.Dense) (float64, *mat.Dense) {
+		rows, cols := yHat.Dims()
+		cfg.checkClasses(cols)
+		p := softmax(yHat)
+		grad := mat.NewDense(rows, cols, nil)
+
+		var l float64
+		for r := 0; r < rows; r++ {
+			t := cfg.targets(y.RawRowView(r))
+			w := cfg.weight(y.RawRowView(r))
+			logSum := logSumExp(yHat.RawRowView(r))
+			for c := 0; c < cols; c++ {
+				l -= w * t[c] * (yHat.At(r, c) - logSum)
+				grad.Set(r, c, w*(p.At(r, c)-t[c])/float64(rows))
+			}
+		}
+
+		return l / float64(rows), grad
+	}
+}
+
+func initCrossEntropyConfig(settings ...CrossEntropySetting) crossEntropyConfig {
+	var cfg crossEntropyConfig
+	for _, s := range settings {
+		s(&cfg)
+	}
+	return cfg
+}
+
+// targets returns the (possibly smoothed) target distribution for a row of y.
+func (c crossEntropyConfig) targets(y []float64) []float64 {
+	t := make([]float64, len(y))
+	for i, v := range y {
+		t[i] = (1-c.labelSmoothing)*v + c.labelSmoothing/float64(len(y))
+	}
+	return t
+}
+
+// checkClasses panics if class weights were given for a different number of classes.
+func (c crossEntropyConfig) checkClasses(classes int) {
+	if c.classWeights != nil && len(c.classWeights) != classes {
+		panic(fmt.Sprintf("got %d class weights for %d classes", len(c.classWeights), classes))
+	}
+}
+
+// weight returns the class weight for a row of y.
+func (c crossEntropyConfig) weight(y []float64) float64 {
+	if c.classWeights == nil {
+		return 1
+	}
+
+	var w float64
+	for i, v := range y {
+		w += c.classWeights[i] * v
+	}
+	return w
+}
+
+func logSumExp(x []float64) float64 {
+	max := x[0]
+	for _, v := range x {
+		max = math.Max(max, v)
+	}
+
+	var sum float64
+	for _, v := range x {
+		sum += math.Exp(v - max)
+	}
+	return max + math.Log(sum)
+}
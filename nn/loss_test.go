@@ -1,8 +1,10 @@
 package nn
 
 import (
+	"math"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -53,3 +55,87 @@ func TestL2Loss(t *testing.T) {
 		t.Errorf("expected gradient and actual gradient for l2 loss do not agree: %v, %v", gradAnalytic, gradNumeric)
 	}
 }
+
+func TestCrossEntropyLoss(t *testing.T) {
+	y := mat.NewDense(2, 3, []float64{
+		0, 1, 0,
+		1, 0, 0,
+	})
+
+	yHat := mat.NewDense(2, 3, []float64{
+		0.2, 0.7, 0.1,
+		0.5, 0.25, 0.25,
+	})
+
+	l, _ := CrossEntropyLoss(y, yHat)
+	assert.InDelta(t, -(math.Log(0.7)+math.Log(0.5))/2, l, 1e-9)
+
+	losses := map[string]Loss{
+		"plain":     CrossEntropyLoss,
+		"smoothing": NewCrossEntropyLoss(WithLabelSmoothing(0.1)),
+		"weights":   NewCrossEntropyLoss(WithClassWeights([]float64{2, 0.5, 1})),
+	}
+
+	for name, loss := range losses {
+		assertLossGradient(t, name, loss, y, yHat)
+	}
+}
+
+func TestSoftmaxCrossEntropyLoss(t *testing.T) {
+	y := mat.NewDense(3, 3, []float64{
+		0, 1, 0,
+		1, 0, 0,
+		0, 0, 1,
+	})
+
+	logits := mat.NewDense(3, 3, []float64{
+		1.5, 2.0, -0.5,
+		300.0, 2.0, 1.0,
+		-3.0, 0.4, 1.2,
+	})
+
+	// The fused loss should agree with a SoftMax layer followed by cross entropy.
+	fused, fusedGrad := SoftmaxCrossEntropyLoss(y, logits)
+
+	s := NewSoftMaxLayer()
+	l, grad := CrossEntropyLoss(y, s.Forwards(logits))
+	grad = s.Backwards(grad)
+
+	assert.InDelta(t, l, fused, 1e-6)
+	delta := mat.NewDense(3, 3, nil)
+	delta.Sub(grad, fusedGrad)
+	assert.InDelta(t, 0, norm(delta), 1e-6)
+
+	losses := map[string]Loss{
+		"plain":     SoftmaxCrossEntropyLoss,
+		"smoothing": NewSoftmaxCrossEntropyLoss(WithLabelSmoothing(0.1)),
+		"weights":   NewSoftmaxCrossEntropyLoss(WithClassWeights([]float64{2, 0.5, 1})),
+	}
+
+	for name, loss := range losses {
+		assertLossGradient(t, name, loss, y, logits)
+	}
+}
+
+func TestClassWeightsMustMatchClasses(t *testing.T) {
+	y := mat.NewDense(1, 3, []float64{0, 1, 0})
+	weights := WithClassWeights([]float64{1, 2})
+
+	assert.PanicsWithValue(t, "got 2 class weights for 3 classes", func() { NewCrossEntropyLoss(weights)(y, y) })
+	assert.PanicsWithValue(t, "got 2 class weights for 3 classes", func() { NewSoftmaxCrossEntropyLoss(weights)(y, y) })
+}
+
+func assertLossGradient(t *testing.T, name string, loss Loss, y, yHat *mat.Dense) {
+	_, gradAnalytic := loss(y, yHat)
+	gradNumeric := NumericGradient(func(x *mat.Dense) float64 {
+		l, _ := loss(y, x)
+		return l
+	}, yHat)
+
+	rows, cols := yHat.Dims()
+	delta := mat.NewDense(rows, cols, nil)
+	delta.Sub(gradAnalytic, gradNumeric)
+	if norm(delta) > 1e-3 {
+		t.Errorf("%s: expected gradient and actual gradient do not agree: %v, %v", name, gradAnalytic, gradNumeric)
+	}
+}
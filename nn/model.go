@@ -0,0 +1,148 @@
+package nn
+
+import (
+	"fmt"
+	"io"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Model is a network frozen for inference. It holds its own copy of the parameters,
+// and Predict records nothing on the layers, so a single Model can be used to make
+// predictions from many goroutines at once.
+type Model struct {
+	layers []Inferer
+
+	// inputSize is the number of columns the model takes, or 0 if any number will do.
+	inputSize int
+}
+
+// inputSizer is implemented by layers that take inputs with a fixed number of columns.
+type inputSizer interface {
+	inputSize() int
+}
+
+// Freeze returns a Model that makes the same predictions as v does with training disabled.
+// The model is made from a copy of v (see Clone), so later training of v does not affect it.
+// Every layer of v must implement Inferer.
+func Freeze(v Value) (*Model, error) {
+	clone, err := Clone(v)
+	if err != nil {
+		return nil, err
+	}
+	return newModel(clone)
+}
+
+// LoadModel reads a network written by FeedForwardNetwork.Save as a Model.
+func LoadModel(r io.Reader) (*Model, error) {
+	n, err := Load(r)
+	if err != nil {
+		return nil, err
+	}
+	return newModel(n)
+}
+
+// LoadModelFile loads a Model from the file at path.
+func LoadModelFile(path string) (*Model, error) {
+	n, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return newModel(n)
+}
+
+func newModel(v Value) (*Model, error) {
+	m := &Model{}
+	if err := m.add(v); err != nil {
+		return nil, err
+	}
+
+	// The layers without a fixed input size all keep the size of their input,
+	// so the first layer that has one gives the size of the input to the model.
+	for _, layer := range m.layers {
+		if l, ok := layer.(inputSizer); ok {
+			m.inputSize = l.inputSize()
+			break
+		}
+	}
+	return m, nil
+}
+
+// add appends the layers of v to the model, flattening any nested networks.
+func (m *Model) add(v Value) error {
+	switch l := v.(type) {
+	case *FeedForwardNetwork:
+		for _, layer := range l.layers {
+			if err := m.add(layer); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *FullyConnectedLayer:
+		if _, ok := l.activation.(Inferer); l.activation != nil && !ok {
+			return fmt.Errorf("activation %T does not implement Inferer", l.activation)
+		}
+	}
+
+	i, ok := v.(Inferer)
+	if !ok {
+		return fmt.Errorf("%T does not implement Inferer", v)
+	}
+	m.layers = append(m.layers, i)
+	return nil
+}
+
+// LayerTypes returns the type of each layer of the model, as given by LayerMarshaler.
+func (m *Model) LayerTypes() []string {
+	result := make([]string, len(m.layers))
+	for i, layer := range m.layers {
+		if l, ok := layer.(LayerMarshaler); ok {
+			result[i] = l.LayerType()
+		} else {
+			result[i] = fmt.Sprintf("%T", layer)
+		}
+	}
+	return result
+}
+
+// InputSize returns the number of columns the model expects in its input,
+// or 0 if it accepts any number, e.g. because it only applies an activation.
+func (m *Model) InputSize() int {
+	return m.inputSize
+}
+
+// NumParameters returns the number of learned values in the model.
+func (m *Model) NumParameters() int {
+	n := 0
+	for _, layer := range m.layers {
+		if v, ok := layer.(Value); ok {
+			for _, p := range v.Parameters() {
+				rows, cols := p.Value.Dims()
+				n += rows * cols
+			}
+		}
+	}
+	return n
+}
+
+// Predict returns the output of the model for each row of x.
+func (m *Model) Predict(x *mat.Dense) *mat.Dense {
+	v := x
+	for _, layer := range m.layers {
+		v = layer.Infer(v)
+	}
+	return v
+}
+
+// PredictProbabilities returns the probability of each class for each row of x.
+// If the model ends with a softmax layer its output is returned as it is,
+// otherwise the output is taken to be logits and a softmax is applied to it.
+func (m *Model) PredictProbabilities(x *mat.Dense) *mat.Dense {
+	y := m.Predict(x)
+	if len(m.layers) > 0 {
+		if _, ok := m.layers[len(m.layers)-1].(*SoftMax); ok {
+			return y
+		}
+	}
+	return softmax(y)
+}
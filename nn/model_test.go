@@ -0,0 +1,127 @@
+package nn
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func newInferenceTestNetwork() *FeedForwardNetwork {
+	image := ImageShape{Channels: 1, Height: 4, Width: 4}
+	conv := NewConv2DLayer(image, 2, 3, WithPadding(1))
+	pool := NewMaxPool2DLayer(conv.OutputShape(), 2)
+	avg := NewAvgPool2DLayer(pool.OutputShape(), 2, WithStride(1))
+
+	return NewFeedForwardNetwork(
+		conv,
+		pool,
+		avg,
+		NewGlobalAveragePoolLayer(avg.OutputShape()),
+		NewBatchNormLayer(2),
+		NewFullyConnectedLayer(2, 6, WithActivation(NewTanh())),
+		NewLayerNormLayer(6),
+		NewPReLU(6),
+		NewDropoutLayer(0.3),
+		NewDenseLayer(6, 3),
+		NewSoftMaxLayer(),
+	)
+}
+
+func TestModelMatchesForwards(t *testing.T) {
+	net := newInferenceTestNetwork()
+	x := randomNonZeroMatrix(5, 16)
+
+	// Train the batch norm statistics away from their initial values.
+	net.SetTrainingEnabled(true)
+	net.Forwards(x)
+
+	model, err := Freeze(net)
+	require.NoError(t, err)
+
+	net.SetTrainingEnabled(false)
+	expected := net.Forwards(x)
+	assert.Equal(t, expected.RawMatrix().Data, model.Predict(x).RawMatrix().Data)
+
+	// Later changes to the network do not affect the model.
+	net.Parameters()[0].Value.Scale(2, net.Parameters()[0].Value)
+	assert.Equal(t, expected.RawMatrix().Data, model.Predict(x).RawMatrix().Data)
+}
+
+func TestModelConcurrentPredict(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, newInferenceTestNetwork().Save(&buf))
+
+	model, err := LoadModel(&buf)
+	require.NoError(t, err)
+
+	xs := make([]*mat.Dense, 8)
+	expected := make([]*mat.Dense, len(xs))
+	for i := range xs {
+		xs[i] = randomNonZeroMatrix(i+1, 16)
+		expected[i] = model.Predict(xs[i])
+	}
+
+	var wg sync.WaitGroup
+	for i := range xs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; n < 10; n++ {
+				assert.Equal(t, expected[i].RawMatrix().Data, model.Predict(xs[i]).RawMatrix().Data)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestFreezeRequiresInferer(t *testing.T) {
+	_, err := newModel(NewFeedForwardNetwork(NewRelu(), noopValue{}))
+	assert.Error(t, err)
+
+	_, err = newModel(NewDenseLayer(2, 2, WithActivation(noopValue{})))
+	assert.Error(t, err)
+}
+
+func TestModelDescription(t *testing.T) {
+	model, err := Freeze(NewFeedForwardNetwork(
+		NewFullyConnectedLayer(3, 4),
+		NewDenseLayer(4, 2),
+		NewSoftMaxLayer(),
+	))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"fully_connected", "fully_connected", "softmax"}, model.LayerTypes())
+	assert.Equal(t, 3*4+4+4*2+2, model.NumParameters())
+}
+
+func TestModelInputSize(t *testing.T) {
+	model, err := Freeze(newInferenceTestNetwork())
+	require.NoError(t, err)
+	assert.Equal(t, 16, model.InputSize())
+
+	model, err = Freeze(NewFeedForwardNetwork(NewRelu(), NewDropoutLayer(0.5), NewDenseLayer(7, 2)))
+	require.NoError(t, err)
+	assert.Equal(t, 7, model.InputSize())
+
+	model, err = Freeze(NewSoftMaxLayer())
+	require.NoError(t, err)
+	assert.Equal(t, 0, model.InputSize())
+}
+
+func TestModelPredictProbabilities(t *testing.T) {
+	x := randomNonZeroMatrix(4, 3)
+	dense := NewDenseLayer(3, 5)
+
+	logits, err := Freeze(dense)
+	require.NoError(t, err)
+	probabilities, err := Freeze(NewFeedForwardNetwork(dense, NewSoftMaxLayer()))
+	require.NoError(t, err)
+
+	expected := probabilities.Predict(x)
+	assert.Equal(t, expected.RawMatrix().Data, probabilities.PredictProbabilities(x).RawMatrix().Data)
+	assert.True(t, mat.EqualApprox(expected, logits.PredictProbabilities(x), 1e-12))
+}
@@ -1,6 +1,8 @@
 package nn
 
 import (
+	"math/rand"
+
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -43,6 +45,38 @@ func (n *FeedForwardNetwork) Weights() []*mat.Dense {
 	return weights
 }
 
+// Parameters returns all learnable parameters from the network, including the bias terms.
+func (n *FeedForwardNetwork) Parameters() []*Parameter {
+	params := make([]*Parameter, 0)
+
+	for _, layer := range n.layers {
+		params = append(params, layer.Parameters()...)
+	}
+
+	return params
+}
+
+// State returns the state of all stateful layers in the network.
+func (n *FeedForwardNetwork) State() []*mat.Dense {
+	state := make([]*mat.Dense, 0)
+
+	for _, layer := range n.layers {
+		state = append(state, StateOf(layer)...)
+	}
+
+	return state
+}
+
+// Seed seeds each layer that is a Seeder with a different seed derived from seed.
+func (n *FeedForwardNetwork) Seed(seed int64) {
+	rng := rand.New(rand.NewSource(seed))
+	for _, layer := range n.layers {
+		if s, ok := layer.(Seeder); ok {
+			s.Seed(rng.Int63())
+		}
+	}
+}
+
 // Backwards flows the gradient back through the network.
 func (n *FeedForwardNetwork) Backwards(x *mat.Dense) *mat.Dense {
 	v := x
@@ -29,6 +29,12 @@ func (noopValue) Weights() []*mat.Dense {
 	return make([]*mat.Dense, 0)
 }
 
+func (noopValue) Parameters() []*Parameter {
+	return make([]*Parameter, 0)
+}
+
+func (noopValue) SetTrainingEnabled(bool) {}
+
 func TestSimpleGradientTest(t *testing.T) {
 	x := mat.NewDense(3, 3, []float64{
 		13, 2.01, -1,
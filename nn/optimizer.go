@@ -0,0 +1,242 @@
+package nn
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const optimizerEpsilon = 1e-8
+
+// Optimizer updates parameters using the gradients accumulated for them by Backwards.
+//
+// Stateful optimizers track their state by the position of each parameter in the slice,
+// so the same slice (in the same order) should be passed to every call to Update.
+type Optimizer interface {
+	Update(params []*Parameter)
+
+	// LearningRate returns the current step size.
+	LearningRate() float64
+
+	// SetLearningRate changes the step size used by subsequent calls to Update.
+	// This makes it possible to implement learning rate schedules.
+	SetLearningRate(float64)
+}
+
+// SGD is plain stochastic gradient descent.
+type SGD struct {
+	learningRate float64
+}
+
+func NewSGD(learningRate float64) *SGD {
+	return &SGD{learningRate: learningRate}
+}
+
+func (o *SGD) LearningRate() float64 {
+	return o.learningRate
+}
+
+func (o *SGD) SetLearningRate(lr float64) {
+	o.learningRate = lr
+}
+
+func (o *SGD) Update(params []*Parameter) {
+	for _, p := range params {
+		v, g := raw(p.Value), raw(p.Grad)
+		for i := range v {
+			v[i] -= o.learningRate * g[i]
+		}
+	}
+}
+
+// Momentum is gradient descent with classical momentum.
+type Momentum struct {
+	learningRate float64
+	momentum     float64
+	velocity     []*mat.Dense
+}
+
+func NewMomentum(learningRate, momentum float64) *Momentum {
+	return &Momentum{learningRate: learningRate, momentum: momentum}
+}
+
+func (o *Momentum) LearningRate() float64 {
+	return o.learningRate
+}
+
+func (o *Momentum) SetLearningRate(lr float64) {
+	o.learningRate = lr
+}
+
+func (o *Momentum) Update(params []*Parameter) {
+	o.velocity = ensureState(o.velocity, params)
+
+	for k, p := range params {
+		v, g, vel := raw(p.Value), raw(p.Grad), raw(o.velocity[k])
+		for i := range v {
+			vel[i] = o.momentum*vel[i] - o.learningRate*g[i]
+			v[i] += vel[i]
+		}
+	}
+}
+
+// Nesterov is gradient descent with Nesterov accelerated momentum.
+type Nesterov struct {
+	learningRate float64
+	momentum     float64
+	velocity     []*mat.Dense
+}
+
+func NewNesterov(learningRate, momentum float64) *Nesterov {
+	return &Nesterov{learningRate: learningRate, momentum: momentum}
+}
+
+func (o *Nesterov) LearningRate() float64 {
+	return o.learningRate
+}
+
+func (o *Nesterov) SetLearningRate(lr float64) {
+	o.learningRate = lr
+}
+
+func (o *Nesterov) Update(params []*Parameter) {
+	o.velocity = ensureState(o.velocity, params)
+
+	for k, p := range params {
+		v, g, vel := raw(p.Value), raw(p.Grad), raw(o.velocity[k])
+		for i := range v {
+			prev := vel[i]
+			vel[i] = o.momentum*vel[i] - o.learningRate*g[i]
+			v[i] += -o.momentum*prev + (1+o.momentum)*vel[i]
+		}
+	}
+}
+
+// RMSProp scales each step by a moving average of the squared gradient.
+type RMSProp struct {
+	learningRate float64
+	decay        float64
+	meanSquare   []*mat.Dense
+}
+
+func NewRMSProp(learningRate, decay float64) *RMSProp {
+	return &RMSProp{learningRate: learningRate, decay: decay}
+}
+
+func (o *RMSProp) LearningRate() float64 {
+	return o.learningRate
+}
+
+func (o *RMSProp) SetLearningRate(lr float64) {
+	o.learningRate = lr
+}
+
+func (o *RMSProp) Update(params []*Parameter) {
+	o.meanSquare = ensureState(o.meanSquare, params)
+
+	for k, p := range params {
+		v, g, s := raw(p.Value), raw(p.Grad), raw(o.meanSquare[k])
+		for i := range v {
+			s[i] = o.decay*s[i] + (1-o.decay)*g[i]*g[i]
+			v[i] -= o.learningRate * g[i] / (math.Sqrt(s[i]) + optimizerEpsilon)
+		}
+	}
+}
+
+// Adagrad scales each step by the accumulated squared gradient.
+type Adagrad struct {
+	learningRate float64
+	sumSquare    []*mat.Dense
+}
+
+func NewAdagrad(learningRate float64) *Adagrad {
+	return &Adagrad{learningRate: learningRate}
+}
+
+func (o *Adagrad) LearningRate() float64 {
+	return o.learningRate
+}
+
+func (o *Adagrad) SetLearningRate(lr float64) {
+	o.learningRate = lr
+}
+
+func (o *Adagrad) Update(params []*Parameter) {
+	o.sumSquare = ensureState(o.sumSquare, params)
+
+	for k, p := range params {
+		v, g, s := raw(p.Value), raw(p.Grad), raw(o.sumSquare[k])
+		for i := range v {
+			s[i] += g[i] * g[i]
+			v[i] -= o.learningRate * g[i] / (math.Sqrt(s[i]) + optimizerEpsilon)
+		}
+	}
+}
+
+// Adam implements the Adam optimizer of Kingma and Ba.
+// Setting a non-zero weight decay gives AdamW, where the decay is applied directly
+// to the weights rather than through the gradient.
+type Adam struct {
+	learningRate float64
+	beta1, beta2 float64
+	weightDecay  float64
+
+	step int
+	m, v []*mat.Dense
+}
+
+func NewAdam(learningRate, beta1, beta2 float64) *Adam {
+	return &Adam{learningRate: learningRate, beta1: beta1, beta2: beta2}
+}
+
+func NewAdamW(learningRate, beta1, beta2, weightDecay float64) *Adam {
+	return &Adam{learningRate: learningRate, beta1: beta1, beta2: beta2, weightDecay: weightDecay}
+}
+
+func (o *Adam) LearningRate() float64 {
+	return o.learningRate
+}
+
+func (o *Adam) SetLearningRate(lr float64) {
+	o.learningRate = lr
+}
+
+func (o *Adam) Update(params []*Parameter) {
+	o.m = ensureState(o.m, params)
+	o.v = ensureState(o.v, params)
+	o.step++
+
+	c1 := 1 - math.Pow(o.beta1, float64(o.step))
+	c2 := 1 - math.Pow(o.beta2, float64(o.step))
+
+	for k, p := range params {
+		x, g, m, v := raw(p.Value), raw(p.Grad), raw(o.m[k]), raw(o.v[k])
+		for i := range x {
+			m[i] = o.beta1*m[i] + (1-o.beta1)*g[i]
+			v[i] = o.beta2*v[i] + (1-o.beta2)*g[i]*g[i]
+
+			x[i] -= o.learningRate * o.weightDecay * x[i]
+			x[i] -= o.learningRate * (m[i] / c1) / (math.Sqrt(v[i]/c2) + optimizerEpsilon)
+		}
+	}
+}
+
+// ensureState returns a slice of zero matrices matching the shapes of params,
+// reusing state if it has already been allocated.
+func ensureState(state []*mat.Dense, params []*Parameter) []*mat.Dense {
+	if len(state) == len(params) {
+		return state
+	}
+
+	state = make([]*mat.Dense, len(params))
+	for i, p := range params {
+		rows, cols := p.Value.Dims()
+		state[i] = mat.NewDense(rows, cols, nil)
+	}
+
+	return state
+}
+
+func raw(m *mat.Dense) []float64 {
+	return m.RawMatrix().Data
+}
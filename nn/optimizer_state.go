@@ -0,0 +1,166 @@
+package nn
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// The optimizers implement encoding.BinaryMarshaler and encoding.BinaryUnmarshaler
+// so that their state can be saved in training checkpoints.
+
+type sgdState struct {
+	LearningRate float64
+}
+
+func (o *SGD) MarshalBinary() ([]byte, error) {
+	return MarshalGob(sgdState{LearningRate: o.learningRate})
+}
+
+func (o *SGD) UnmarshalBinary(data []byte) error {
+	var s sgdState
+	if err := UnmarshalGob(data, &s); err != nil {
+		return err
+	}
+
+	o.learningRate = s.LearningRate
+	return nil
+}
+
+type velocityState struct {
+	LearningRate float64
+	Momentum     float64
+	Velocity     []*mat.Dense
+}
+
+func (o *Momentum) MarshalBinary() ([]byte, error) {
+	return MarshalGob(velocityState{
+		LearningRate: o.learningRate,
+		Momentum:     o.momentum,
+		Velocity:     o.velocity,
+	})
+}
+
+func (o *Momentum) UnmarshalBinary(data []byte) error {
+	var s velocityState
+	if err := UnmarshalGob(data, &s); err != nil {
+		return err
+	}
+
+	o.learningRate, o.momentum, o.velocity = s.LearningRate, s.Momentum, s.Velocity
+	return nil
+}
+
+func (o *Nesterov) MarshalBinary() ([]byte, error) {
+	return MarshalGob(velocityState{
+		LearningRate: o.learningRate,
+		Momentum:     o.momentum,
+		Velocity:     o.velocity,
+	})
+}
+
+func (o *Nesterov) UnmarshalBinary(data []byte) error {
+	var s velocityState
+	if err := UnmarshalGob(data, &s); err != nil {
+		return err
+	}
+
+	o.learningRate, o.momentum, o.velocity = s.LearningRate, s.Momentum, s.Velocity
+	return nil
+}
+
+type rmsPropState struct {
+	LearningRate float64
+	Decay        float64
+	MeanSquare   []*mat.Dense
+}
+
+func (o *RMSProp) MarshalBinary() ([]byte, error) {
+	return MarshalGob(rmsPropState{
+		LearningRate: o.learningRate,
+		Decay:        o.decay,
+		MeanSquare:   o.meanSquare,
+	})
+}
+
+func (o *RMSProp) UnmarshalBinary(data []byte) error {
+	var s rmsPropState
+	if err := UnmarshalGob(data, &s); err != nil {
+		return err
+	}
+
+	o.learningRate, o.decay, o.meanSquare = s.LearningRate, s.Decay, s.MeanSquare
+	return nil
+}
+
+type adagradState struct {
+	LearningRate float64
+	SumSquare    []*mat.Dense
+}
+
+func (o *Adagrad) MarshalBinary() ([]byte, error) {
+	return MarshalGob(adagradState{
+		LearningRate: o.learningRate,
+		SumSquare:    o.sumSquare,
+	})
+}
+
+func (o *Adagrad) UnmarshalBinary(data []byte) error {
+	var s adagradState
+	if err := UnmarshalGob(data, &s); err != nil {
+		return err
+	}
+
+	o.learningRate, o.sumSquare = s.LearningRate, s.SumSquare
+	return nil
+}
+
+type adamState struct {
+	LearningRate float64
+	Beta1, Beta2 float64
+	WeightDecay  float64
+	Step         int
+	M, V         []*mat.Dense
+}
+
+func (o *Adam) MarshalBinary() ([]byte, error) {
+	return MarshalGob(adamState{
+		LearningRate: o.learningRate,
+		Beta1:        o.beta1,
+		Beta2:        o.beta2,
+		WeightDecay:  o.weightDecay,
+		Step:         o.step,
+		M:            o.m,
+		V:            o.v,
+	})
+}
+
+func (o *Adam) UnmarshalBinary(data []byte) error {
+	var s adamState
+	if err := UnmarshalGob(data, &s); err != nil {
+		return err
+	}
+
+	o.learningRate = s.LearningRate
+	o.beta1, o.beta2 = s.Beta1, s.Beta2
+	o.weightDecay = s.WeightDecay
+	o.step = s.Step
+	o.m, o.v = s.M, s.V
+	return nil
+}
+
+// MarshalGob encodes v with encoding/gob. It is used to implement encoding.BinaryMarshaler
+// for the optimizers here, and for other types whose state is saved in training checkpoints.
+func MarshalGob(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalGob decodes data written by MarshalGob into v.
+func UnmarshalGob(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
@@ -0,0 +1,48 @@
+package nn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestOptimizersMinimizeQuadratic(t *testing.T) {
+	optimizers := map[string]Optimizer{
+		"sgd":      NewSGD(0.1),
+		"momentum": NewMomentum(0.05, 0.9),
+		"nesterov": NewNesterov(0.05, 0.9),
+		"rmsprop":  NewRMSProp(0.01, 0.9),
+		"adagrad":  NewAdagrad(0.5),
+		"adam":     NewAdam(0.05, 0.9, 0.999),
+		"adamw":    NewAdamW(0.05, 0.9, 0.999, 0.0001),
+	}
+
+	target := mat.NewDense(1, 3, []float64{1, -2, 3})
+
+	for name, opt := range optimizers {
+		p := NewParameter(mat.NewDense(1, 3, []float64{5, 5, 5}))
+		params := []*Parameter{p}
+
+		// Minimize 1/2 |x - target|^2.
+		for i := 0; i < 1000; i++ {
+			ZeroGradients(params)
+			p.Grad.Sub(p.Value, target)
+			opt.Update(params)
+		}
+
+		for c := 0; c < 3; c++ {
+			assert.InDelta(t, target.At(0, c), p.Value.At(0, c), 0.05, name)
+		}
+	}
+}
+
+func TestZeroGradients(t *testing.T) {
+	p := NewParameter(mat.NewDense(2, 2, []float64{1, 2, 3, 4}))
+	p.Grad.Copy(p.Value)
+
+	ZeroGradients([]*Parameter{p})
+
+	assert.Equal(t, 0.0, norm(p.Grad))
+	assert.Equal(t, 4.0, p.Value.At(1, 1))
+}
@@ -0,0 +1,373 @@
+package nn
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// MaxPool2D takes the maximum over windows of each channel of an image.
+// The stride defaults to the window size.
+type MaxPool2D struct {
+	pool2D
+
+	// argmax records, for each output of each row, the input it was taken from.
+	argmax [][]int
+}
+
+func NewMaxPool2DLayer(input ImageShape, size int, settings ...LayerSetting) *MaxPool2D {
+	return &MaxPool2D{pool2D: newPool2D(input, size, settings)}
+}
+
+func (l *MaxPool2D) Forwards(x *mat.Dense) *mat.Dense {
+	result, argmax := l.forwards(x)
+	l.argmax = argmax
+	return result
+}
+
+// Infer returns the output of the layer without recording anything for Backwards.
+func (l *MaxPool2D) Infer(x *mat.Dense) *mat.Dense {
+	result, _ := l.forwards(x)
+	return result
+}
+
+func (l *MaxPool2D) forwards(x *mat.Dense) (*mat.Dense, [][]int) {
+	rows, _ := x.Dims()
+	result := mat.NewDense(rows, l.output.Size(), nil)
+	argmax := make([][]int, rows)
+
+	for r := 0; r < rows; r++ {
+		xRow, out := x.RawRowView(r), result.RawRowView(r)
+		argmax[r] = make([]int, len(out))
+
+		l.forEachWindow(func(o int, window []int) {
+			max, j := math.Inf(-1), -1
+			for _, k := range window {
+				if k >= 0 && xRow[k] > max {
+					max, j = xRow[k], k
+				}
+			}
+			out[o] = max
+			argmax[r][o] = j
+		})
+	}
+
+	return result, argmax
+}
+
+func (l *MaxPool2D) Backwards(grad *mat.Dense) *mat.Dense {
+	rows, _ := grad.Dims()
+	result := mat.NewDense(rows, l.input.Size(), nil)
+
+	for r := 0; r < rows; r++ {
+		xGrad := result.RawRowView(r)
+		for o, g := range grad.RawRowView(r) {
+			xGrad[l.argmax[r][o]] += g
+		}
+	}
+
+	return result
+}
+
+func (l *MaxPool2D) LayerType() string {
+	return "max_pool2d"
+}
+
+// AvgPool2D takes the mean over windows of each channel of an image.
+// Padding counts towards the mean as zeros. The stride defaults to the window size.
+type AvgPool2D struct {
+	pool2D
+}
+
+func NewAvgPool2DLayer(input ImageShape, size int, settings ...LayerSetting) *AvgPool2D {
+	return &AvgPool2D{pool2D: newPool2D(input, size, settings)}
+}
+
+func (l *AvgPool2D) Forwards(x *mat.Dense) *mat.Dense {
+	rows, _ := x.Dims()
+	result := mat.NewDense(rows, l.output.Size(), nil)
+	n := float64(l.size * l.size)
+
+	for r := 0; r < rows; r++ {
+		xRow, out := x.RawRowView(r), result.RawRowView(r)
+		l.forEachWindow(func(o int, window []int) {
+			for _, j := range window {
+				if j >= 0 {
+					out[o] += xRow[j] / n
+				}
+			}
+		})
+	}
+
+	return result
+}
+
+// Infer returns the output of the layer. Forwards records nothing, so this is the same.
+func (l *AvgPool2D) Infer(x *mat.Dense) *mat.Dense {
+	return l.Forwards(x)
+}
+
+func (l *AvgPool2D) Backwards(grad *mat.Dense) *mat.Dense {
+	rows, _ := grad.Dims()
+	result := mat.NewDense(rows, l.input.Size(), nil)
+	n := float64(l.size * l.size)
+
+	for r := 0; r < rows; r++ {
+		gradRow, xGrad := grad.RawRowView(r), result.RawRowView(r)
+		l.forEachWindow(func(o int, window []int) {
+			for _, j := range window {
+				if j >= 0 {
+					xGrad[j] += gradRow[o] / n
+				}
+			}
+		})
+	}
+
+	return result
+}
+
+func (l *AvgPool2D) LayerType() string {
+	return "avg_pool2d"
+}
+
+// GlobalAveragePool averages each channel of an image down to a single value.
+type GlobalAveragePool struct {
+	input ImageShape
+}
+
+func NewGlobalAveragePoolLayer(input ImageShape) *GlobalAveragePool {
+	return &GlobalAveragePool{input: input}
+}
+
+// OutputShape returns the shape of the images produced by this layer.
+func (l *GlobalAveragePool) OutputShape() ImageShape {
+	return ImageShape{Channels: l.input.Channels, Height: 1, Width: 1}
+}
+
+func (l *GlobalAveragePool) SetTrainingEnabled(b bool) {
+	// Pooling behaves the same in training and inference.
+}
+
+func (l *GlobalAveragePool) Forwards(x *mat.Dense) *mat.Dense {
+	rows, _ := x.Dims()
+	plane := l.input.Height * l.input.Width
+	result := mat.NewDense(rows, l.input.Channels, nil)
+
+	for r := 0; r < rows; r++ {
+		xRow := x.RawRowView(r)
+		for c := 0; c < l.input.Channels; c++ {
+			sum := 0.0
+			for _, v := range xRow[c*plane : (c+1)*plane] {
+				sum += v
+			}
+			result.Set(r, c, sum/float64(plane))
+		}
+	}
+
+	return result
+}
+
+// Infer returns the output of the layer. Forwards records nothing, so this is the same.
+func (l *GlobalAveragePool) Infer(x *mat.Dense) *mat.Dense {
+	return l.Forwards(x)
+}
+
+func (l *GlobalAveragePool) Backwards(grad *mat.Dense) *mat.Dense {
+	rows, _ := grad.Dims()
+	plane := l.input.Height * l.input.Width
+	result := mat.NewDense(rows, l.input.Size(), nil)
+
+	for r := 0; r < rows; r++ {
+		xGrad := result.RawRowView(r)
+		for i := range xGrad {
+			xGrad[i] = grad.At(r, i/plane) / float64(plane)
+		}
+	}
+
+	return result
+}
+
+func (l *GlobalAveragePool) Weights() []*mat.Dense {
+	return make([]*mat.Dense, 0)
+}
+
+func (l *GlobalAveragePool) Parameters() []*Parameter {
+	return make([]*Parameter, 0)
+}
+
+func (l *GlobalAveragePool) inputSize() int {
+	return l.input.Size()
+}
+
+func (l *GlobalAveragePool) LayerType() string {
+	return "global_average_pool"
+}
+
+func (l *GlobalAveragePool) MarshalLayer() (json.RawMessage, error) {
+	return json.Marshal(l.input)
+}
+
+func decodeGlobalAveragePool(config json.RawMessage) (Value, error) {
+	var input ImageShape
+	if err := json.Unmarshal(config, &input); err != nil {
+		return nil, err
+	}
+	if err := checkImageShape(input); err != nil {
+		return nil, err
+	}
+	return NewGlobalAveragePoolLayer(input), nil
+}
+
+// pool2D holds what is common to the windowed pooling layers.
+type pool2D struct {
+	input, output ImageShape
+	size          int
+	cfg           layerConfig
+
+	// index holds the input positions covered by each window of a single channel,
+	// or -1 where the window falls in the padding.
+	index []int
+}
+
+func newPool2D(input ImageShape, size int, settings []LayerSetting) pool2D {
+	if size < 1 {
+		panic(fmt.Sprintf("window size must be at least 1, got %d", size))
+	}
+	cfg := initLayerConfig(append([]LayerSetting{WithStride(size)}, settings...)...)
+	if err := checkWindow(cfg); err != nil {
+		panic(err.Error())
+	}
+	if cfg.padding >= size {
+		// Windows at the edges would then cover only padding, and have no input to take.
+		panic(fmt.Sprintf("padding %d must be less than the window size %d", cfg.padding, size))
+	}
+
+	output := ImageShape{
+		Channels: input.Channels,
+		Height:   convOutputSize(input.Height, size, cfg),
+		Width:    convOutputSize(input.Width, size, cfg),
+	}
+	if output.Height <= 0 || output.Width <= 0 {
+		panic(fmt.Sprintf("window of size %d does not fit input of size %dx%d", size, input.Height, input.Width))
+	}
+
+	plane := ImageShape{Channels: 1, Height: input.Height, Width: input.Width}
+	return pool2D{
+		input:  input,
+		output: output,
+		size:   size,
+		cfg:    cfg,
+		index:  im2colIndex(plane, ImageShape{1, output.Height, output.Width}, size, cfg),
+	}
+}
+
+// OutputShape returns the shape of the images produced by this layer.
+func (l *pool2D) OutputShape() ImageShape {
+	return l.output
+}
+
+func (l *pool2D) SetTrainingEnabled(b bool) {
+	// Pooling behaves the same in training and inference.
+}
+
+func (l *pool2D) Weights() []*mat.Dense {
+	return make([]*mat.Dense, 0)
+}
+
+func (l *pool2D) Parameters() []*Parameter {
+	return make([]*Parameter, 0)
+}
+
+// forEachWindow calls f with the index of each output in a row, along with the
+// positions in the input row of the window it is computed from.
+func (l *pool2D) forEachWindow(f func(o int, window []int)) {
+	windowSize := l.size * l.size
+	patches := l.output.Height * l.output.Width
+	plane := l.input.Height * l.input.Width
+	window := make([]int, windowSize)
+
+	for c := 0; c < l.input.Channels; c++ {
+		for p := 0; p < patches; p++ {
+			for k, j := range l.index[p*windowSize : (p+1)*windowSize] {
+				if j >= 0 {
+					j += c * plane
+				}
+				window[k] = j
+			}
+			f(c*patches+p, window)
+		}
+	}
+}
+
+type pool2DConfig struct {
+	Input    ImageShape `json:"input"`
+	Size     int        `json:"size"`
+	Stride   int        `json:"stride"`
+	Padding  int        `json:"padding"`
+	Dilation int        `json:"dilation"`
+}
+
+// validate returns an error for the configurations newPool2D would panic on.
+func (c pool2DConfig) validate() error {
+	if err := checkImageShape(c.Input); err != nil {
+		return err
+	}
+	if err := checkDimension("window size", c.Size); err != nil {
+		return err
+	}
+
+	cfg := initLayerConfig(c.settings()...)
+	if err := checkWindow(cfg); err != nil {
+		return err
+	}
+	if c.Padding >= c.Size {
+		return fmt.Errorf("padding %d must be less than the window size %d", c.Padding, c.Size)
+	}
+	if convOutputSize(c.Input.Height, c.Size, cfg) <= 0 || convOutputSize(c.Input.Width, c.Size, cfg) <= 0 {
+		return fmt.Errorf("window of size %d does not fit input of size %dx%d", c.Size, c.Input.Height, c.Input.Width)
+	}
+	return nil
+}
+
+// settings returns the settings that recreate the saved layer.
+func (c pool2DConfig) settings() []LayerSetting {
+	return []LayerSetting{WithStride(c.Stride), WithPadding(c.Padding), WithDilation(c.Dilation)}
+}
+
+func (l *pool2D) inputSize() int {
+	return l.input.Size()
+}
+
+func (l *pool2D) MarshalLayer() (json.RawMessage, error) {
+	return json.Marshal(pool2DConfig{
+		Input:    l.input,
+		Size:     l.size,
+		Stride:   l.cfg.stride,
+		Padding:  l.cfg.padding,
+		Dilation: l.cfg.dilation,
+	})
+}
+
+func decodeMaxPool2D(config json.RawMessage) (Value, error) {
+	var cfg pool2DConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return NewMaxPool2DLayer(cfg.Input, cfg.Size, cfg.settings()...), nil
+}
+
+func decodeAvgPool2D(config json.RawMessage) (Value, error) {
+	var cfg pool2DConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return NewAvgPool2DLayer(cfg.Input, cfg.Size, cfg.settings()...), nil
+}
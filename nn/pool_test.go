@@ -0,0 +1,105 @@
+package nn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestMaxPool2D(t *testing.T) {
+	l := NewMaxPool2DLayer(ImageShape{Channels: 2, Height: 2, Width: 4}, 2)
+
+	x := mat.NewDense(1, 16, []float64{
+		1, 5, 2, 0,
+		3, 4, 8, 7,
+
+		-1, -2, 6, 6.5,
+		-3, -4, 9, -9,
+	})
+
+	assert.Equal(t, ImageShape{Channels: 2, Height: 1, Width: 2}, l.OutputShape())
+	assert.Equal(t, []float64{5, 8, -1, 9}, l.Forwards(x).RawRowView(0))
+
+	// The gradient is routed only to the maximum of each window.
+	grad := l.Backwards(mat.NewDense(1, 4, []float64{1, 2, 3, 4}))
+	assert.Equal(t, []float64{
+		0, 1, 0, 0,
+		0, 0, 2, 0,
+
+		3, 0, 0, 0,
+		0, 0, 4, 0,
+	}, grad.RawRowView(0))
+}
+
+func TestPool2DRejectsPaddingCoveringWindows(t *testing.T) {
+	input := ImageShape{Channels: 1, Height: 2, Width: 2}
+	assert.Panics(t, func() { NewMaxPool2DLayer(input, 2, WithPadding(2)) })
+	assert.Panics(t, func() { NewAvgPool2DLayer(input, 2, WithPadding(3)) })
+
+	_, err := decodeMaxPool2D([]byte(`{"input": {"channels": 1, "height": 2, "width": 2}, "size": 2, "stride": 2, "padding": 2, "dilation": 1}`))
+	assert.Error(t, err)
+
+	// Padding less than the window size leaves an input in every window.
+	l := NewMaxPool2DLayer(input, 2, WithPadding(1))
+	x := mat.NewDense(1, 4, []float64{-1, -2, -3, -4})
+	assert.Equal(t, []float64{-1, -2, -3, -4}, l.Forwards(x).RawRowView(0))
+	assert.Equal(t, []float64{1, 1, 1, 1}, l.Backwards(mat.NewDense(1, 4, []float64{1, 1, 1, 1})).RawRowView(0))
+}
+
+func TestPool2DRejectsInvalidWindows(t *testing.T) {
+	input := ImageShape{Channels: 1, Height: 4, Width: 4}
+	assert.Panics(t, func() { NewMaxPool2DLayer(input, 0) })
+	assert.Panics(t, func() { NewAvgPool2DLayer(input, 5, WithStride(1)) })
+
+	for _, config := range []string{
+		`{"input": {"channels": 1, "height": 4, "width": 4}, "size": 0, "stride": 1, "padding": 0, "dilation": 1}`,
+		`{"input": {"channels": 1, "height": 4, "width": 4}, "size": 5, "stride": 1, "padding": 0, "dilation": 1}`,
+		`{"input": {"channels": 1, "height": 4, "width": 4}, "size": 3, "stride": 1, "padding": 0, "dilation": 2}`,
+		`{"input": {"channels": 1, "height": 0, "width": 4}, "size": 2, "stride": 1, "padding": 0, "dilation": 1}`,
+	} {
+		_, err := decodeMaxPool2D([]byte(config))
+		assert.Error(t, err, config)
+	}
+}
+
+func TestAvgPool2D(t *testing.T) {
+	l := NewAvgPool2DLayer(ImageShape{Channels: 1, Height: 2, Width: 4}, 2)
+
+	x := mat.NewDense(1, 8, []float64{
+		1, 5, 2, 0,
+		3, 4, 8, 7,
+	})
+
+	assert.Equal(t, []float64{3.25, 4.25}, l.Forwards(x).RawRowView(0))
+}
+
+func TestGlobalAveragePool(t *testing.T) {
+	l := NewGlobalAveragePoolLayer(ImageShape{Channels: 2, Height: 1, Width: 3})
+
+	x := mat.NewDense(2, 6, []float64{
+		1, 2, 3, 4, 5, 6,
+		-1, -2, -3, 0, 0, 3,
+	})
+
+	assert.Equal(t, []float64{2, 5, -2, 1}, l.Forwards(x).RawMatrix().Data)
+}
+
+func TestPoolingNumericGradient(t *testing.T) {
+	input := ImageShape{Channels: 2, Height: 5, Width: 4}
+	layers := map[string]Value{
+		"max":             NewMaxPool2DLayer(input, 2),
+		"max overlapping": NewMaxPool2DLayer(input, 3, WithStride(1)),
+		"max padding":     NewMaxPool2DLayer(input, 2, WithPadding(1)),
+		"avg":             NewAvgPool2DLayer(input, 2),
+		"avg overlapping": NewAvgPool2DLayer(input, 3, WithStride(1), WithPadding(1)),
+		"global":          NewGlobalAveragePoolLayer(input),
+	}
+
+	for name, l := range layers {
+		x := randomNonZeroMatrix(2, input.Size())
+		y := randomNonZeroMatrix(2, l.Forwards(x).RawMatrix().Cols)
+
+		assert.NoError(t, SimpleGradientTest(l, x, y), name)
+	}
+}
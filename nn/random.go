@@ -6,12 +6,35 @@ import (
 	"gonum.org/v1/gonum/mat"
 )
 
+// globalRand draws from the top-level functions of math/rand. It is used by layers
+// that are not given a random number generator with WithRand, and like the top-level
+// functions it is safe for concurrent use.
+var globalRand = rand.New(globalSource{})
+
+type globalSource struct{}
+
+func (globalSource) Int63() int64 {
+	return rand.Int63()
+}
+
+func (globalSource) Uint64() uint64 {
+	return rand.Uint64()
+}
+
+// Seed does nothing, the global source is seeded with rand.Seed.
+func (globalSource) Seed(int64) {}
+
 func NewRandomMatrix(r, c int) *mat.Dense {
+	return NewRandomMatrixFrom(globalRand, r, c)
+}
+
+// NewRandomMatrixFrom returns a matrix with entries drawn uniformly from [-1, 1) using rng.
+func NewRandomMatrixFrom(rng *rand.Rand, r, c int) *mat.Dense {
 	result := mat.NewDense(r, c, nil)
 
 	for i := 0; i < r; i++ {
 		for j := 0; j < c; j++ {
-			result.Set(i, j, rand.Float64()*2-1)
+			result.Set(i, j, rng.Float64()*2-1)
 		}
 	}
 
@@ -1,6 +1,7 @@
 package nn
 
 import (
+	"encoding/json"
 	"math"
 
 	"gonum.org/v1/gonum/mat"
@@ -23,6 +24,11 @@ func (r *Relu) Forwards(x *mat.Dense) *mat.Dense {
 	return relu(x)
 }
 
+// Infer returns the output of the layer without recording anything for Backwards.
+func (r *Relu) Infer(x *mat.Dense) *mat.Dense {
+	return relu(x)
+}
+
 func (r *Relu) Backwards(grad *mat.Dense) *mat.Dense {
 	return reluBackwards(grad, r.x)
 }
@@ -31,6 +37,22 @@ func (r *Relu) Weights() []*mat.Dense {
 	return make([]*mat.Dense, 0)
 }
 
+func (r *Relu) Parameters() []*Parameter {
+	return make([]*Parameter, 0)
+}
+
+func (r *Relu) LayerType() string {
+	return "relu"
+}
+
+func (r *Relu) MarshalLayer() (json.RawMessage, error) {
+	return nil, nil
+}
+
+func decodeRelu(json.RawMessage) (Value, error) {
+	return NewRelu(), nil
+}
+
 func relu(x *mat.Dense) *mat.Dense {
 	rows, cols := x.Dims()
 
@@ -0,0 +1,288 @@
+package nn
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	formatName    = "gonn"
+	formatVersion = 1
+)
+
+// LayerMarshaler is implemented by layers that can be saved with FeedForwardNetwork.Save.
+type LayerMarshaler interface {
+	// LayerType returns the name the layer's decoder was registered under with RegisterLayer.
+	LayerType() string
+
+	// MarshalLayer returns a JSON description of the layer's architecture.
+	// The values of the layer's parameters and state are saved separately.
+	MarshalLayer() (json.RawMessage, error)
+}
+
+// LayerDecoder constructs a layer from the description returned by its MarshalLayer.
+// The saved parameter values (and state, for Stateful layers) are copied into the
+// returned layer, so they must have the same shapes and be returned in the same order.
+type LayerDecoder func(config json.RawMessage) (Value, error)
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string]LayerDecoder{}
+)
+
+// RegisterLayer makes a layer type available to Load.
+// Custom layers should register themselves from an init function.
+func RegisterLayer(layerType string, decode LayerDecoder) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, ok := registry[layerType]; ok {
+		panic(fmt.Sprintf("layer type registered twice: %s", layerType))
+	}
+	registry[layerType] = decode
+}
+
+func init() {
+	RegisterLayer("fully_connected", decodeFullyConnectedLayer)
+	RegisterLayer("relu", decodeRelu)
+	RegisterLayer("softmax", decodeSoftMax)
+	RegisterLayer("dropout", decodeDropoutLayer)
+	RegisterLayer("conv2d", decodeConv2D)
+	RegisterLayer("max_pool2d", decodeMaxPool2D)
+	RegisterLayer("avg_pool2d", decodeAvgPool2D)
+	RegisterLayer("global_average_pool", decodeGlobalAveragePool)
+	RegisterLayer("batch_norm", decodeBatchNorm)
+	RegisterLayer("layer_norm", decodeLayerNorm)
+	RegisterLayer("sigmoid", func(json.RawMessage) (Value, error) { return NewSigmoid(), nil })
+	RegisterLayer("tanh", func(json.RawMessage) (Value, error) { return NewTanh(), nil })
+	RegisterLayer("leaky_relu", decodeLeakyReLU)
+	RegisterLayer("elu", decodeELU)
+	RegisterLayer("selu", func(json.RawMessage) (Value, error) { return NewSELU(), nil })
+	RegisterLayer("gelu", func(json.RawMessage) (Value, error) { return NewGELU(), nil })
+	RegisterLayer("swish", func(json.RawMessage) (Value, error) { return NewSwish(), nil })
+	RegisterLayer("softplus", func(json.RawMessage) (Value, error) { return NewSoftplus(), nil })
+	RegisterLayer("prelu", decodePReLU)
+}
+
+type networkSpec struct {
+	Format  string      `json:"format"`
+	Version int         `json:"version"`
+	Layers  []layerSpec `json:"layers"`
+}
+
+type layerSpec struct {
+	Type       string          `json:"type"`
+	Config     json.RawMessage `json:"config,omitempty"`
+	Parameters []matrixSpec    `json:"parameters,omitempty"`
+	State      []matrixSpec    `json:"state,omitempty"`
+}
+
+type matrixSpec struct {
+	Rows int       `json:"rows"`
+	Cols int       `json:"cols"`
+	Data []float64 `json:"data"`
+}
+
+// Save writes the architecture and parameters of the network to w.
+// Every layer must implement LayerMarshaler.
+func (n *FeedForwardNetwork) Save(w io.Writer) error {
+	spec := networkSpec{
+		Format:  formatName,
+		Version: formatVersion,
+		Layers:  make([]layerSpec, len(n.layers)),
+	}
+
+	for i, layer := range n.layers {
+		l, err := encodeLayer(layer)
+		if err != nil {
+			return fmt.Errorf("layer %d: %s", i, err)
+		}
+		spec.Layers[i] = l
+	}
+
+	return json.NewEncoder(w).Encode(spec)
+}
+
+// SaveFile saves the network to the file at path, creating or truncating it.
+func (n *FeedForwardNetwork) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = n.Save(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Load reads a network written by FeedForwardNetwork.Save.
+func Load(r io.Reader) (*FeedForwardNetwork, error) {
+	var spec networkSpec
+	if err := json.NewDecoder(r).Decode(&spec); err != nil {
+		return nil, err
+	}
+
+	if spec.Format != formatName {
+		return nil, fmt.Errorf("unexpected file format")
+	}
+	if spec.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported format version: %d", spec.Version)
+	}
+
+	layers := make([]Value, len(spec.Layers))
+	for i, l := range spec.Layers {
+		layer, err := decodeLayer(l)
+		if err != nil {
+			return nil, fmt.Errorf("layer %d: %s", i, err)
+		}
+		layers[i] = layer
+	}
+
+	return NewFeedForwardNetwork(layers...), nil
+}
+
+// LoadFile loads a network from the file at path.
+func LoadFile(path string) (*FeedForwardNetwork, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// Clone returns a deep copy of v, including its parameters and state, made by saving
+// and loading it. v must be a FeedForwardNetwork or implement LayerMarshaler.
+func Clone(v Value) (Value, error) {
+	if n, ok := v.(*FeedForwardNetwork); ok {
+		var buf bytes.Buffer
+		if err := n.Save(&buf); err != nil {
+			return nil, err
+		}
+		return Load(&buf)
+	}
+
+	spec, err := encodeLayer(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeLayer(spec)
+}
+
+func encodeLayer(v Value) (layerSpec, error) {
+	m, ok := v.(LayerMarshaler)
+	if !ok {
+		return layerSpec{}, fmt.Errorf("%T does not implement LayerMarshaler", v)
+	}
+
+	config, err := m.MarshalLayer()
+	if err != nil {
+		return layerSpec{}, err
+	}
+
+	params := v.Parameters()
+	spec := layerSpec{
+		Type:       m.LayerType(),
+		Config:     config,
+		Parameters: make([]matrixSpec, len(params)),
+	}
+
+	for i, p := range params {
+		spec.Parameters[i] = encodeMatrix(p.Value)
+	}
+
+	for _, s := range StateOf(v) {
+		spec.State = append(spec.State, encodeMatrix(s))
+	}
+
+	return spec, nil
+}
+
+func decodeLayer(spec layerSpec) (Value, error) {
+	registryLock.RLock()
+	decode, ok := registry[spec.Type]
+	registryLock.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown layer type: %s", spec.Type)
+	}
+
+	v, err := decode(spec.Config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", spec.Type, err)
+	}
+
+	params := v.Parameters()
+	if len(params) != len(spec.Parameters) {
+		return nil, fmt.Errorf("%s: expected %d parameters, found %d", spec.Type, len(params), len(spec.Parameters))
+	}
+
+	for i, p := range params {
+		if err := decodeMatrix(p.Value, spec.Parameters[i]); err != nil {
+			return nil, fmt.Errorf("%s: parameter %d: %s", spec.Type, i, err)
+		}
+	}
+
+	state := StateOf(v)
+	if len(state) != len(spec.State) {
+		return nil, fmt.Errorf("%s: expected %d state values, found %d", spec.Type, len(state), len(spec.State))
+	}
+
+	for i, s := range state {
+		if err := decodeMatrix(s, spec.State[i]); err != nil {
+			return nil, fmt.Errorf("%s: state %d: %s", spec.Type, i, err)
+		}
+	}
+
+	return v, nil
+}
+
+func encodeMatrix(m *mat.Dense) matrixSpec {
+	rows, cols := m.Dims()
+	data := make([]float64, 0, rows*cols)
+	for r := 0; r < rows; r++ {
+		data = append(data, m.RawRowView(r)...)
+	}
+
+	return matrixSpec{Rows: rows, Cols: cols, Data: data}
+}
+
+// checkDimension returns an error unless n, the saved size of a layer's dimension, is positive.
+// Decoders check this before calling constructors, which panic on empty matrices.
+func checkDimension(name string, n int) error {
+	if n < 1 {
+		return fmt.Errorf("%s must be at least 1, got %d", name, n)
+	}
+	return nil
+}
+
+// checkImageShape returns an error unless every dimension of s is positive.
+func checkImageShape(s ImageShape) error {
+	if s.Channels < 1 || s.Height < 1 || s.Width < 1 {
+		return fmt.Errorf("image shape must be positive, got %dx%dx%d", s.Channels, s.Height, s.Width)
+	}
+	return nil
+}
+
+// decodeMatrix copies the saved values into dst, which must already have the right shape.
+func decodeMatrix(dst *mat.Dense, spec matrixSpec) error {
+	rows, cols := dst.Dims()
+	if rows != spec.Rows || cols != spec.Cols {
+		return fmt.Errorf("shape mismatch: %dx%d != %dx%d", spec.Rows, spec.Cols, rows, cols)
+	}
+	if len(spec.Data) != rows*cols {
+		return fmt.Errorf("expected %d values, found %d", rows*cols, len(spec.Data))
+	}
+
+	dst.Copy(mat.NewDense(rows, cols, spec.Data))
+	return nil
+}
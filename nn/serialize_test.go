@@ -0,0 +1,168 @@
+package nn
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	net := NewFeedForwardNetwork(
+		NewFullyConnectedLayer(4, 5),
+		NewDropoutLayer(0.25),
+		NewFullyConnectedLayer(5, 3),
+		NewSoftMaxLayer(),
+	)
+	net.SetTrainingEnabled(false)
+
+	var buf bytes.Buffer
+	require.NoError(t, net.Save(&buf))
+
+	loaded, err := Load(&buf)
+	require.NoError(t, err)
+	loaded.SetTrainingEnabled(false)
+
+	x := mat.NewDense(2, 4, []float64{
+		1, 2, 3, 4,
+		-0.5, 0.25, 10, -3,
+	})
+
+	assert.Equal(t, net.Forwards(x).RawMatrix().Data, loaded.Forwards(x).RawMatrix().Data)
+}
+
+func TestConvSaveLoadRoundTrip(t *testing.T) {
+	conv := NewConv2DLayer(ImageShape{Channels: 1, Height: 4, Width: 4}, 2, 2, WithPadding(1), WithDilation(2), WithStride(2))
+	net := NewFeedForwardNetwork(
+		conv,
+		NewMaxPool2DLayer(conv.OutputShape(), 2, WithStride(1)),
+		NewAvgPool2DLayer(ImageShape{Channels: 2, Height: 1, Width: 1}, 1),
+		NewDenseLayer(2, 3),
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, net.Save(&buf))
+
+	loaded, err := Load(&buf)
+	require.NoError(t, err)
+
+	x := randomNonZeroMatrix(2, 16)
+	assert.Equal(t, net.Forwards(x).RawMatrix().Data, loaded.Forwards(x).RawMatrix().Data)
+}
+
+func TestPoolSaveLoadRoundTripKeepsDilation(t *testing.T) {
+	input := ImageShape{Channels: 1, Height: 8, Width: 8}
+	for _, l := range []Value{
+		NewMaxPool2DLayer(input, 2, WithStride(1), WithDilation(2)),
+		NewAvgPool2DLayer(input, 2, WithStride(1), WithDilation(2)),
+	} {
+		clone, err := Clone(l)
+		require.NoError(t, err)
+
+		x := randomNonZeroMatrix(2, input.Size())
+		assert.Equal(t, 36, l.Forwards(x).RawMatrix().Cols)
+		assert.Equal(t, l.Forwards(x).RawMatrix().Data, clone.Forwards(x).RawMatrix().Data)
+	}
+}
+
+func TestLoadRejectsUnknownVersion(t *testing.T) {
+	_, err := Load(strings.NewReader(`{"format": "gonn", "version": 1000, "layers": []}`))
+	assert.Error(t, err)
+
+	_, err = Load(strings.NewReader(`{"format": "other", "version": 1, "layers": []}`))
+	assert.Error(t, err)
+}
+
+func TestLoadRejectsUnknownLayer(t *testing.T) {
+	_, err := Load(strings.NewReader(`{"format": "gonn", "version": 1, "layers": [{"type": "unknown"}]}`))
+	assert.Error(t, err)
+}
+
+func TestLoadRejectsWrongShape(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewFeedForwardNetwork(NewFullyConnectedLayer(2, 2)).Save(&buf))
+
+	corrupted := strings.Replace(buf.String(), `"rows":2`, `"rows":3`, 1)
+	_, err := Load(strings.NewReader(corrupted))
+	assert.Error(t, err)
+}
+
+type scaleLayer struct {
+	noopValue
+	factor float64
+}
+
+func (l *scaleLayer) Forwards(x *mat.Dense) *mat.Dense {
+	result := mat.DenseCopyOf(x)
+	result.Scale(l.factor, x)
+	return result
+}
+
+func (l *scaleLayer) LayerType() string {
+	return "test_scale"
+}
+
+func (l *scaleLayer) MarshalLayer() (json.RawMessage, error) {
+	return json.Marshal(l.factor)
+}
+
+func TestCustomLayerRegistration(t *testing.T) {
+	RegisterLayer("test_scale", func(config json.RawMessage) (Value, error) {
+		l := &scaleLayer{}
+		return l, json.Unmarshal(config, &l.factor)
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, NewFeedForwardNetwork(&scaleLayer{factor: 3}).Save(&buf))
+
+	loaded, err := Load(&buf)
+	require.NoError(t, err)
+
+	y := loaded.Forwards(mat.NewDense(1, 2, []float64{1, 2}))
+	assert.Equal(t, []float64{3, 6}, y.RawMatrix().Data)
+}
+
+func TestSaveRequiresLayerMarshaler(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Error(t, NewFeedForwardNetwork(noopValue{}).Save(&buf))
+}
+
+func TestClone(t *testing.T) {
+	layer := NewBatchNormLayer(3)
+	layer.runningMean.Set(0, 1, 0.5)
+
+	clone, err := Clone(layer)
+	require.NoError(t, err)
+	assert.Equal(t, layer.State(), StateOf(clone))
+
+	// The clone does not share any matrices with the original.
+	layer.gamma.Value.Set(0, 0, 2)
+	assert.Equal(t, 1.0, clone.Parameters()[0].Value.At(0, 0))
+
+	net := NewFeedForwardNetwork(NewFullyConnectedLayer(2, 3), layer)
+	netClone, err := Clone(net)
+	require.NoError(t, err)
+	for i, p := range net.Parameters() {
+		assert.Equal(t, p.Value, netClone.Parameters()[i].Value)
+		assert.False(t, p.Value == netClone.Parameters()[i].Value)
+	}
+}
+
+func TestLoadRejectsEmptyDimensions(t *testing.T) {
+	layers := map[string]string{
+		"fully connected":     `{"type": "fully_connected", "config": {"input": 0, "output": 3}}`,
+		"fully connected out": `{"type": "fully_connected", "config": {"input": 2, "output": -1}}`,
+		"prelu":               `{"type": "prelu", "config": 0}`,
+		"batch norm":          `{"type": "batch_norm", "config": {"dimension": 0}}`,
+		"layer norm":          `{"type": "layer_norm", "config": {"dimension": -2}}`,
+		"global average pool": `{"type": "global_average_pool", "config": {"channels": 1, "height": 0, "width": 2}}`,
+	}
+	for name, layer := range layers {
+		_, err := Load(strings.NewReader(`{"format": "gonn", "version": 1, "layers": [` + layer + `]}`))
+		assert.Error(t, err, name)
+	}
+}
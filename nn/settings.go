@@ -0,0 +1,90 @@
+package nn
+
+import (
+	"math/rand"
+)
+
+// LayerSetting configures optional behaviour of a layer.
+type LayerSetting func(*layerConfig)
+
+type layerConfig struct {
+	stride     int
+	padding    int
+	dilation   int
+	activation Value
+	weightInit Initializer
+	biasInit   Initializer
+	rng        *rand.Rand
+}
+
+// WithStride sets the step between neighbouring windows of a convolution or pooling layer.
+func WithStride(n int) LayerSetting {
+	return func(c *layerConfig) {
+		c.stride = n
+	}
+}
+
+// WithPadding pads the input of a convolution or pooling layer by n zeros on every side.
+func WithPadding(n int) LayerSetting {
+	return func(c *layerConfig) {
+		c.padding = n
+	}
+}
+
+// WithDilation sets the spacing between the kernel elements of a convolution.
+func WithDilation(n int) LayerSetting {
+	return func(c *layerConfig) {
+		c.dilation = n
+	}
+}
+
+// WithActivation sets the activation applied to the output of a fully connected layer.
+// Passing nil gives a linear layer.
+func WithActivation(v Value) LayerSetting {
+	return func(c *layerConfig) {
+		c.activation = v
+	}
+}
+
+// WithWeightInitializer sets how the weights of a fully connected or convolution layer are initialized.
+// By default this is chosen to suit the layer's activation.
+func WithWeightInitializer(init Initializer) LayerSetting {
+	return func(c *layerConfig) {
+		c.weightInit = init
+	}
+}
+
+// WithBiasInitializer sets how the bias of a fully connected or convolution layer is initialized.
+// Defaults to zeros.
+func WithBiasInitializer(init Initializer) LayerSetting {
+	return func(c *layerConfig) {
+		c.biasInit = init
+	}
+}
+
+// WithRand sets the source of randomness for initializing a layer's weights, or
+// for the masks of a dropout layer. Giving each layer a seeded generator makes
+// training reproducible. Defaults to the global math/rand source.
+// sgd.SGD reseeds dropout layers at the start of every epoch (see Seeder), so their
+// masks then follow the seed given to sgd.WithSeed instead.
+//
+// A *rand.Rand is not safe for concurrent use, so layers that may be used from
+// different goroutines should not share one.
+func WithRand(rng *rand.Rand) LayerSetting {
+	return func(c *layerConfig) {
+		c.rng = rng
+	}
+}
+
+func initLayerConfig(settings ...LayerSetting) layerConfig {
+	cfg := layerConfig{
+		stride:   1,
+		dilation: 1,
+		biasInit: Zeros(),
+		rng:      globalRand,
+	}
+	for _, s := range settings {
+		s(&cfg)
+	}
+	return cfg
+}
@@ -1,6 +1,7 @@
 package nn
 
 import (
+	"encoding/json"
 	"math"
 
 	"gonum.org/v1/gonum/mat"
@@ -23,6 +24,11 @@ func (s *SoftMax) Forwards(x *mat.Dense) *mat.Dense {
 	return softmax(x)
 }
 
+// Infer returns the output of the layer without recording anything for Backwards.
+func (s *SoftMax) Infer(x *mat.Dense) *mat.Dense {
+	return softmax(x)
+}
+
 func (s *SoftMax) Backwards(grad *mat.Dense) *mat.Dense {
 	result := make([]float64, 0)
 	rows, cols := s.sx.Dims()
@@ -45,6 +51,22 @@ func (s *SoftMax) Weights() []*mat.Dense {
 	return make([]*mat.Dense, 0)
 }
 
+func (s *SoftMax) Parameters() []*Parameter {
+	return make([]*Parameter, 0)
+}
+
+func (s *SoftMax) LayerType() string {
+	return "softmax"
+}
+
+func (s *SoftMax) MarshalLayer() (json.RawMessage, error) {
+	return nil, nil
+}
+
+func decodeSoftMax(json.RawMessage) (Value, error) {
+	return NewSoftMaxLayer(), nil
+}
+
 func softmaxLayerGradient(grad, x *mat.Dense) *mat.Dense {
 	return nil
 }
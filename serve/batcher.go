@@ -0,0 +1,172 @@
+package serve
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rosshemsley/gonn/nn"
+	"gonum.org/v1/gonum/mat"
+)
+
+// errClosed is returned for predictions requested after the batcher has been closed.
+var errClosed = errors.New("server is shutting down")
+
+type prediction struct {
+	x *mat.Dense
+
+	// probabilities says whether the model's class probabilities are wanted, rather than its outputs.
+	probabilities bool
+
+	result chan predictionResult
+}
+
+type predictionResult struct {
+	y   *mat.Dense
+	err error
+}
+
+// batcher combines the rows of concurrent prediction requests into larger batches,
+// so that the model makes fewer, larger matrix multiplications.
+type batcher struct {
+	model        *nn.Model
+	maxBatchSize int
+	timeout      time.Duration
+
+	requests chan prediction
+	done     chan struct{}
+
+	// lock guards closed, and is held for reading while sending to requests.
+	lock   sync.RWMutex
+	closed bool
+}
+
+func newBatcher(model *nn.Model, maxBatchSize int, timeout time.Duration) *batcher {
+	b := &batcher{
+		model:        model,
+		maxBatchSize: maxBatchSize,
+		timeout:      timeout,
+		requests:     make(chan prediction),
+		done:         make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// predict returns the output of the model for each row of x, or its class probabilities
+// (see nn.Model.PredictProbabilities), once it has been run as part of a batch.
+func (b *batcher) predict(x *mat.Dense, probabilities bool) (*mat.Dense, error) {
+	p := prediction{x: x, probabilities: probabilities, result: make(chan predictionResult, 1)}
+
+	b.lock.RLock()
+	if b.closed {
+		b.lock.RUnlock()
+		return nil, errClosed
+	}
+	b.requests <- p
+	b.lock.RUnlock()
+
+	r := <-p.result
+	return r.y, r.err
+}
+
+// close stops accepting predictions, and returns once those already accepted are done.
+func (b *batcher) close() {
+	b.lock.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.requests)
+	}
+	b.lock.Unlock()
+
+	<-b.done
+}
+
+func (b *batcher) run() {
+	defer close(b.done)
+
+	for p := range b.requests {
+		batch := []prediction{p}
+		rows, _ := p.x.Dims()
+
+		timer := time.NewTimer(b.timeout)
+	collect:
+		for rows < b.maxBatchSize {
+			select {
+			case p, ok := <-b.requests:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, p)
+				r, _ := p.x.Dims()
+				rows += r
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		b.predictBatch(batch)
+	}
+}
+
+// groupKey identifies the requests in a batch that can be run through the model together.
+type groupKey struct {
+	cols          int
+	probabilities bool
+}
+
+// predictBatch runs the model once for each distinct input width and kind of output
+// in the batch, and sends each request its share of the output.
+func (b *batcher) predictBatch(batch []prediction) {
+	groups := make(map[groupKey][]prediction)
+	var keys []groupKey
+	for _, p := range batch {
+		_, cols := p.x.Dims()
+		key := groupKey{cols: cols, probabilities: p.probabilities}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], p)
+	}
+
+	for _, key := range keys {
+		group, cols := groups[key], key.cols
+
+		var data []float64
+		for _, p := range group {
+			data = append(data, p.x.RawMatrix().Data...)
+		}
+		x := mat.NewDense(len(data)/cols, cols, data)
+
+		y, err := b.safePredict(x, key.probabilities)
+		start := 0
+		for _, p := range group {
+			if err != nil {
+				p.result <- predictionResult{err: err}
+				continue
+			}
+
+			rows, _ := p.x.Dims()
+			_, yCols := y.Dims()
+			p.result <- predictionResult{y: mat.DenseCopyOf(y.Slice(start, start+rows, 0, yCols))}
+			start += rows
+		}
+	}
+}
+
+// safePredict converts a panic in the model into an error, so that one bad batch does not
+// stop the server. Inputs are checked before they are batched, so this is a server fault.
+func (b *batcher) safePredict(x *mat.Dense, probabilities bool) (y *mat.Dense, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("prediction failed: %v", r)
+		}
+	}()
+
+	if probabilities {
+		return b.model.PredictProbabilities(x), nil
+	}
+	return b.model.Predict(x), nil
+}
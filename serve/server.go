@@ -0,0 +1,250 @@
+// Package serve exposes a trained model over HTTP, with JSON endpoints for predictions.
+//
+// The endpoints are:
+//
+//	POST /v1/predict        {"inputs": [[...], ...]} -> {"outputs": [[...], ...]}
+//	POST /v1/probabilities  {"inputs": [[...], ...]} -> {"classes": [...], "probabilities": [[...], ...]}
+//	GET  /v1/metadata       a description of the model
+//	GET  /healthz           {"status": "ok"}
+//
+// Concurrent prediction requests are combined into batches before being passed to the model.
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rosshemsley/gonn/nn"
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	defaultMaxBatchSize = 64
+	defaultBatchTimeout = 5 * time.Millisecond
+
+	// maxRequestBytes limits the size of the body of a prediction request.
+	maxRequestBytes = 32 << 20
+)
+
+type Setting func(*config)
+
+type config struct {
+	maxBatchSize int
+	batchTimeout time.Duration
+	name         string
+}
+
+// WithMaxBatchSize sets the number of rows at which a batch is run without waiting for more requests.
+// Defaults to 64.
+func WithMaxBatchSize(n int) Setting {
+	return func(c *config) {
+		c.maxBatchSize = n
+	}
+}
+
+// WithBatchTimeout sets how long to wait for more requests to add to a batch
+// once the first request arrives. Defaults to 5ms.
+func WithBatchTimeout(d time.Duration) Setting {
+	return func(c *config) {
+		c.batchTimeout = d
+	}
+}
+
+// WithName sets the name reported for the model in its metadata, e.g. the file it was loaded from.
+func WithName(name string) Setting {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+// Server is an http.Handler that serves predictions from a model.
+type Server struct {
+	cfg     config
+	model   *nn.Model
+	batcher *batcher
+	mux     *http.ServeMux
+}
+
+func NewServer(model *nn.Model, settings ...Setting) *Server {
+	cfg := config{
+		maxBatchSize: defaultMaxBatchSize,
+		batchTimeout: defaultBatchTimeout,
+	}
+	for _, s := range settings {
+		s(&cfg)
+	}
+
+	s := &Server{
+		cfg:     cfg,
+		model:   model,
+		batcher: newBatcher(model, cfg.maxBatchSize, cfg.batchTimeout),
+		mux:     http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/v1/predict", s.handlePredict)
+	s.mux.HandleFunc("/v1/probabilities", s.handleProbabilities)
+	s.mux.HandleFunc("/v1/metadata", s.handleMetadata)
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close stops accepting predictions, and returns once those in progress have finished.
+// It should be called after the http.Server has been shut down.
+func (s *Server) Close() {
+	s.batcher.close()
+}
+
+type predictRequest struct {
+	Inputs [][]float64 `json:"inputs"`
+}
+
+type predictResponse struct {
+	Outputs [][]float64 `json:"outputs"`
+}
+
+type probabilitiesResponse struct {
+	Classes       []int       `json:"classes"`
+	Probabilities [][]float64 `json:"probabilities"`
+}
+
+type metadataResponse struct {
+	Name         string   `json:"name,omitempty"`
+	Layers       []string `json:"layers"`
+	Parameters   int      `json:"parameters"`
+	MaxBatchSize int      `json:"max_batch_size"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) handlePredict(w http.ResponseWriter, r *http.Request) {
+	y, ok := s.predict(w, r, false)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, predictResponse{Outputs: rowsOf(y)})
+}
+
+// handleProbabilities returns the probability of each class, along with the most likely class.
+// If the model does not end with a softmax layer, its outputs are taken to be logits.
+func (s *Server) handleProbabilities(w http.ResponseWriter, r *http.Request) {
+	y, ok := s.predict(w, r, true)
+	if !ok {
+		return
+	}
+
+	probabilities := rowsOf(y)
+	result := probabilitiesResponse{
+		Classes:       make([]int, len(probabilities)),
+		Probabilities: probabilities,
+	}
+	for i, row := range probabilities {
+		for j := range row {
+			if row[j] > row[result.Classes[i]] {
+				result.Classes[i] = j
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleMetadata(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, metadataResponse{
+		Name:         s.cfg.name,
+		Layers:       s.model.LayerTypes(),
+		Parameters:   s.model.NumParameters(),
+		MaxBatchSize: s.cfg.maxBatchSize,
+	})
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// predict decodes the inputs of a prediction request and runs them through the model,
+// returning class probabilities if probabilities is set.
+// If it fails, the error has already been written to w.
+func (s *Server) predict(w http.ResponseWriter, r *http.Request, probabilities bool) (*mat.Dense, bool) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return nil, false
+	}
+
+	var req predictRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %s", err))
+		return nil, false
+	}
+
+	x, err := matrixOf(req.Inputs)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	if _, cols := x.Dims(); s.model.InputSize() != 0 && cols != s.model.InputSize() {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("inputs have %d values, the model expects %d", cols, s.model.InputSize()))
+		return nil, false
+	}
+
+	// The inputs have been checked above, so a failure from here on is a fault in the server.
+	y, err := s.batcher.predict(x, probabilities)
+	if err == errClosed {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return nil, false
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	return y, true
+}
+
+func matrixOf(rows [][]float64) (*mat.Dense, error) {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil, fmt.Errorf("inputs must have at least one row and one column")
+	}
+
+	cols := len(rows[0])
+	data := make([]float64, 0, len(rows)*cols)
+	for i, row := range rows {
+		if len(row) != cols {
+			return nil, fmt.Errorf("input %d has %d values, expected %d", i, len(row), cols)
+		}
+		data = append(data, row...)
+	}
+
+	return mat.NewDense(len(rows), cols, data), nil
+}
+
+func rowsOf(m *mat.Dense) [][]float64 {
+	rows, _ := m.Dims()
+	result := make([][]float64, rows)
+	for i := range result {
+		result[i] = append([]float64(nil), m.RawRowView(i)...)
+	}
+	return result
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
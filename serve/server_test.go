@@ -0,0 +1,194 @@
+package serve
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rosshemsley/gonn/nn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func newTestServer(t *testing.T, layers ...nn.Value) (*Server, *nn.Model) {
+	model, err := nn.Freeze(nn.NewFeedForwardNetwork(layers...))
+	require.NoError(t, err)
+
+	s := NewServer(model, WithMaxBatchSize(4), WithBatchTimeout(time.Millisecond), WithName("test"))
+	t.Cleanup(s.Close)
+	return s, model
+}
+
+func post(t *testing.T, s *Server, path string, body interface{}) *httptest.ResponseRecorder {
+	b, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(b)))
+	return w
+}
+
+func TestPredict(t *testing.T) {
+	s, model := newTestServer(t, nn.NewFullyConnectedLayer(3, 4), nn.NewDenseLayer(4, 2))
+	inputs := [][]float64{{1, 2, 3}, {-1, 0, 1}}
+
+	w := post(t, s, "/v1/predict", predictRequest{Inputs: inputs})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp predictResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	expected := model.Predict(mat.NewDense(2, 3, []float64{1, 2, 3, -1, 0, 1}))
+	assert.Equal(t, rowsOf(expected), resp.Outputs)
+}
+
+func TestPredictRejectsBadInputs(t *testing.T) {
+	s, _ := newTestServer(t, nn.NewDenseLayer(3, 2))
+
+	w := post(t, s, "/v1/predict", predictRequest{Inputs: [][]float64{{1, 2, 3}, {1}}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = post(t, s, "/v1/predict", predictRequest{Inputs: [][]float64{{1, 2}}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = post(t, s, "/v1/predict", predictRequest{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/predict", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestPredictRejectsWrongInputSize(t *testing.T) {
+	// A convolution would silently ignore extra columns.
+	s, _ := newTestServer(t,
+		nn.NewRelu(),
+		nn.NewConv2DLayer(nn.ImageShape{Channels: 1, Height: 2, Width: 2}, 1, 2),
+	)
+
+	w := post(t, s, "/v1/predict", predictRequest{Inputs: [][]float64{{1, 2, 3, 4, 5}}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = post(t, s, "/v1/probabilities", predictRequest{Inputs: [][]float64{{1, 2, 3}}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = post(t, s, "/v1/predict", predictRequest{Inputs: [][]float64{{1, 2, 3, 4}}})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+// failingLayer is a layer that panics whenever it is used, standing in for a fault in the model.
+type failingLayer struct{}
+
+func (failingLayer) Forwards(x *mat.Dense) *mat.Dense       { panic("layer failed") }
+func (failingLayer) Backwards(grad *mat.Dense) *mat.Dense   { panic("layer failed") }
+func (failingLayer) Infer(x *mat.Dense) *mat.Dense          { panic("layer failed") }
+func (failingLayer) SetTrainingEnabled(bool)                {}
+func (failingLayer) Weights() []*mat.Dense                  { return nil }
+func (failingLayer) Parameters() []*nn.Parameter            { return nil }
+func (failingLayer) LayerType() string                      { return "serve_test_failing" }
+func (failingLayer) MarshalLayer() (json.RawMessage, error) { return nil, nil }
+
+func init() {
+	nn.RegisterLayer("serve_test_failing", func(json.RawMessage) (nn.Value, error) { return failingLayer{}, nil })
+}
+
+func TestPredictReportsModelFailures(t *testing.T) {
+	s, _ := newTestServer(t, nn.NewDenseLayer(2, 2), failingLayer{})
+
+	w := post(t, s, "/v1/predict", predictRequest{Inputs: [][]float64{{1, 2}}})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "layer failed")
+
+	// Invalid inputs are still the fault of the client.
+	w = post(t, s, "/v1/predict", predictRequest{Inputs: [][]float64{{1, 2, 3}}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestProbabilities(t *testing.T) {
+	// Without a softmax layer, the outputs are treated as logits.
+	s, _ := newTestServer(t, nn.NewDenseLayer(2, 3, nn.WithWeightInitializer(nn.Zeros())))
+
+	w := post(t, s, "/v1/probabilities", predictRequest{Inputs: [][]float64{{1, 2}}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp probabilitiesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []int{0}, resp.Classes)
+	assert.InDeltaSlice(t, []float64{1.0 / 3, 1.0 / 3, 1.0 / 3}, resp.Probabilities[0], 1e-12)
+
+	s, model := newTestServer(t, nn.NewDenseLayer(2, 3), nn.NewSoftMaxLayer())
+	w = post(t, s, "/v1/probabilities", predictRequest{Inputs: [][]float64{{1, 2}}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	expected := model.Predict(mat.NewDense(1, 2, []float64{1, 2}))
+	assert.Equal(t, expected.RawRowView(0), resp.Probabilities[0])
+}
+
+func TestMetadataAndHealth(t *testing.T) {
+	s, _ := newTestServer(t, nn.NewDenseLayer(3, 2), nn.NewSoftMaxLayer())
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/metadata", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var meta metadataResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &meta))
+	assert.Equal(t, metadataResponse{
+		Name:         "test",
+		Layers:       []string{"fully_connected", "softmax"},
+		Parameters:   8,
+		MaxBatchSize: 4,
+	}, meta)
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status": "ok"}`, w.Body.String())
+}
+
+func TestBatcherCombinesRequests(t *testing.T) {
+	model, err := nn.Freeze(nn.NewFeedForwardNetwork(nn.NewDenseLayer(2, 2)))
+	require.NoError(t, err)
+
+	b := newBatcher(model, 100, 50*time.Millisecond)
+	defer b.close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// Requests with different widths share a batch without affecting each other.
+			cols := 2
+			if i%3 == 0 {
+				cols = 5
+			}
+			x := mat.NewDense(i+1, cols, nil)
+			x.Apply(func(r, c int, _ float64) float64 { return float64(i*r + c) }, x)
+
+			y, err := b.predict(x, false)
+			if cols != 2 {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, model.Predict(x).RawMatrix().Data, y.RawMatrix().Data)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestCloseRejectsNewPredictions(t *testing.T) {
+	s, _ := newTestServer(t, nn.NewDenseLayer(2, 2))
+	s.Close()
+
+	w := post(t, s, "/v1/predict", predictRequest{Inputs: [][]float64{{1, 2}}})
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
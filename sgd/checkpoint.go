@@ -0,0 +1,224 @@
+package sgd
+
+import (
+	"encoding"
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/rosshemsley/gonn/nn"
+	"gonum.org/v1/gonum/mat"
+)
+
+// checkpointVersion is incremented whenever the fields of checkpoint change, so that
+// checkpoints written by older versions are rejected rather than decoded incorrectly.
+const checkpointVersion = 3
+
+// checkpoint holds everything needed to resume training where it left off.
+type checkpoint struct {
+	Version int
+
+	// Epoch is the number of epochs that have been completed.
+	Epoch int
+
+	// Seed is used to derive the random number generator for each epoch, so that a
+	// resumed run shuffles its batches and draws its dropout masks exactly as the
+	// original would have.
+	Seed int64
+
+	// Snapshot holds the values of the network's parameters, followed by its state.
+	Snapshot  []*mat.Dense
+	Optimizer []byte
+	Schedule  []byte
+
+	BestEpoch    int
+	BestLoss     float64
+	BestSnapshot []*mat.Dense
+	BadEpochs    int
+
+	History History
+}
+
+// trainingState is the part of the training loop that is saved in checkpoints.
+type trainingState struct {
+	epoch int
+	seed  int64
+
+	bestEpoch    int
+	bestLoss     float64
+	bestSnapshot []*mat.Dense
+
+	// badEpochs counts the epochs since the validation loss last improved.
+	badEpochs int
+
+	history History
+}
+
+func newTrainingState(seed int64) *trainingState {
+	return &trainingState{seed: seed, bestLoss: math.Inf(1)}
+}
+
+// observe records the validation loss at the end of an epoch, taking a copy of the
+// snapshot if it improves on the best seen so far by more than minDelta.
+func (s *trainingState) observe(validationLoss, minDelta float64, snapshot []*mat.Dense) {
+	s.epoch++
+	if validationLoss < s.bestLoss-minDelta {
+		s.bestEpoch = s.epoch
+		s.bestLoss = validationLoss
+		s.bestSnapshot = copySnapshot(snapshot)
+		s.badEpochs = 0
+	} else {
+		s.badEpochs++
+	}
+}
+
+// RestoreBest loads the parameters that gave the lowest validation loss during
+// the run that wrote the checkpoint at path into net, which must have the same
+// architecture as the network that was trained.
+func RestoreBest(path string, net nn.Value) error {
+	c, err := readCheckpoint(path)
+	if err != nil {
+		return err
+	}
+	if c.BestSnapshot == nil {
+		return fmt.Errorf("checkpoint has no best snapshot")
+	}
+
+	return restoreSnapshot(snapshotOf(net), c.BestSnapshot)
+}
+
+func saveCheckpoint(path string, state *trainingState, snapshot []*mat.Dense, cfg Config) error {
+	c := checkpoint{
+		Version:      checkpointVersion,
+		Epoch:        state.epoch,
+		Seed:         state.seed,
+		Snapshot:     copySnapshot(snapshot),
+		BestEpoch:    state.bestEpoch,
+		BestLoss:     state.bestLoss,
+		BestSnapshot: state.bestSnapshot,
+		BadEpochs:    state.badEpochs,
+		History:      state.history,
+	}
+
+	var err error
+	if c.Optimizer, err = marshalState(cfg.optimizer); err != nil {
+		return fmt.Errorf("failed to save optimizer: %s", err)
+	}
+	if c.Schedule, err = marshalState(cfg.schedule); err != nil {
+		return fmt.Errorf("failed to save schedule: %s", err)
+	}
+
+	data, err := nn.MarshalGob(c)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a crash never leaves a partial checkpoint.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func readCheckpoint(path string) (*checkpoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var c checkpoint
+	if err := gob.NewDecoder(f).Decode(&c); err != nil {
+		return nil, err
+	}
+	if c.Version != checkpointVersion {
+		return nil, fmt.Errorf("unsupported checkpoint version: %d", c.Version)
+	}
+
+	return &c, nil
+}
+
+// resume restores the state of a training run from the checkpoint at path.
+func resume(path string, snapshot []*mat.Dense, cfg Config) (*trainingState, error) {
+	c, err := readCheckpoint(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := restoreSnapshot(snapshot, c.Snapshot); err != nil {
+		return nil, err
+	}
+	if err := unmarshalState(cfg.optimizer, c.Optimizer); err != nil {
+		return nil, fmt.Errorf("failed to restore optimizer: %s", err)
+	}
+	if err := unmarshalState(cfg.schedule, c.Schedule); err != nil {
+		return nil, fmt.Errorf("failed to restore schedule: %s", err)
+	}
+
+	return &trainingState{
+		epoch:        c.Epoch,
+		seed:         c.Seed,
+		bestEpoch:    c.BestEpoch,
+		bestLoss:     c.BestLoss,
+		bestSnapshot: c.BestSnapshot,
+		badEpochs:    c.BadEpochs,
+		history:      c.History,
+	}, nil
+}
+
+// snapshotOf returns everything that training changes in v: the values of its parameters,
+// followed by its state. Unlike Value.Weights, this includes the biases. The matrices are
+// those of v itself, so they can be restored into, and must be copied to be kept.
+func snapshotOf(v nn.Value) []*mat.Dense {
+	snapshot := make([]*mat.Dense, 0)
+	for _, p := range v.Parameters() {
+		snapshot = append(snapshot, p.Value)
+	}
+	return append(snapshot, nn.StateOf(v)...)
+}
+
+// copySnapshot returns a deep copy of a snapshot.
+func copySnapshot(snapshot []*mat.Dense) []*mat.Dense {
+	result := make([]*mat.Dense, len(snapshot))
+	for i, w := range snapshot {
+		result[i] = mat.DenseCopyOf(w)
+	}
+	return result
+}
+
+// restoreSnapshot copies the saved values into the matrices of a snapshot returned by snapshotOf.
+func restoreSnapshot(snapshot []*mat.Dense, values []*mat.Dense) error {
+	if len(snapshot) != len(values) {
+		return fmt.Errorf("expected %d values, found %d", len(snapshot), len(values))
+	}
+
+	for i, w := range snapshot {
+		rows, cols := w.Dims()
+		vRows, vCols := values[i].Dims()
+		if rows != vRows || cols != vCols {
+			return fmt.Errorf("value %d: shape mismatch: %dx%d != %dx%d", i, vRows, vCols, rows, cols)
+		}
+		w.Copy(values[i])
+	}
+
+	return nil
+}
+
+// marshalState saves the state of v if it has any.
+func marshalState(v interface{}) ([]byte, error) {
+	m, ok := v.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, nil
+	}
+	return m.MarshalBinary()
+}
+
+func unmarshalState(v interface{}, data []byte) error {
+	u, ok := v.(encoding.BinaryUnmarshaler)
+	if !ok || data == nil {
+		return nil
+	}
+	return u.UnmarshalBinary(data)
+}
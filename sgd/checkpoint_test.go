@@ -0,0 +1,141 @@
+package sgd
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rosshemsley/gonn/nn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestResumeMatchesUninterruptedRun(t *testing.T) {
+	x, y := randomDataset(60, 3, 2)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.checkpoint")
+
+	initial := nn.NewFeedForwardNetwork(
+		nn.NewFullyConnectedLayer(3, 4),
+		nn.NewBatchNormLayer(4),
+		nn.NewFullyConnectedLayer(4, 2),
+	)
+
+	settings := func(epochs int) []Setting {
+		return []Setting{
+			WithEpochs(epochs),
+			WithBatchSize(8),
+			WithOptimizer(nn.NewAdam(0.01, 0.9, 0.999)),
+			WithSchedule(NewReduceOnPlateau(0.5, 0, 0.001)),
+			WithSeed(42),
+		}
+	}
+
+	uninterrupted := copyNetwork(t, initial)
+	require.NoError(t, train(x, y, nn.L2Loss, uninterrupted, settings(4)...))
+
+	resumed := copyNetwork(t, initial)
+	require.NoError(t, train(x, y, nn.L2Loss, resumed, append(settings(2), WithCheckpoint(path, 1))...))
+
+	// A fresh network and optimizer pick up where the first run stopped.
+	resumed = copyNetwork(t, initial)
+	require.NoError(t, train(x, y, nn.L2Loss, resumed, append(settings(4), WithResume(path))...))
+
+	for i, w := range snapshotOf(uninterrupted) {
+		assert.Equal(t, w.RawMatrix().Data, snapshotOf(resumed)[i].RawMatrix().Data)
+	}
+}
+
+func TestResumeWithDropoutMatchesUninterruptedRun(t *testing.T) {
+	x, y := randomDataset(60, 3, 2)
+	path := filepath.Join(t.TempDir(), "run.checkpoint")
+
+	initial := nn.NewFeedForwardNetwork(
+		nn.NewFullyConnectedLayer(3, 8),
+		nn.NewDropoutLayer(0.5),
+		nn.NewDenseLayer(8, 2),
+	)
+
+	settings := func(epochs int) []Setting {
+		return []Setting{WithEpochs(epochs), WithBatchSize(8), WithSeed(7)}
+	}
+
+	uninterrupted := copyNetwork(t, initial)
+	require.NoError(t, train(x, y, nn.L2Loss, uninterrupted, settings(4)...))
+
+	interrupted := copyNetwork(t, initial)
+	require.NoError(t, train(x, y, nn.L2Loss, interrupted, append(settings(2), WithCheckpoint(path, 1))...))
+
+	// The dropout layer of the resumed network starts from the global random source,
+	// but is reseeded from the checkpoint's seed before it draws its first mask.
+	resumed := copyNetwork(t, initial)
+	require.NoError(t, train(x, y, nn.L2Loss, resumed, append(settings(4), WithResume(path))...))
+
+	for i, w := range snapshotOf(uninterrupted) {
+		assert.Equal(t, w.RawMatrix().Data, snapshotOf(resumed)[i].RawMatrix().Data)
+	}
+}
+
+func TestRestoreBest(t *testing.T) {
+	x, y := randomDataset(40, 3, 2)
+	path := filepath.Join(t.TempDir(), "run.checkpoint")
+
+	net := nn.NewFeedForwardNetwork(nn.NewFullyConnectedLayer(3, 2))
+	require.NoError(t, train(x, y, nn.L2Loss, net, WithEpochs(3), WithCheckpoint(path, 10)))
+
+	_, err := os.Stat(path)
+	require.NoError(t, err)
+
+	c, err := readCheckpoint(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, c.Epoch)
+
+	require.NoError(t, RestoreBest(path, net))
+	for i, w := range snapshotOf(net) {
+		assert.Equal(t, c.BestSnapshot[i].RawMatrix().Data, w.RawMatrix().Data)
+	}
+}
+
+func TestResumeRejectsOtherVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.checkpoint")
+	data, err := nn.MarshalGob(checkpoint{Version: checkpointVersion - 1, Epoch: 1})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	x, y := randomDataset(20, 3, 2)
+	net := nn.NewFeedForwardNetwork(nn.NewFullyConnectedLayer(3, 2))
+	assert.Error(t, train(x, y, nn.L2Loss, net, WithEpochs(2), WithResume(path)))
+	assert.Error(t, RestoreBest(path, net))
+}
+
+func randomDataset(rows, xCols, yCols int) (x, y *mat.Dense) {
+	r := rand.New(rand.NewSource(1))
+	x = mat.NewDense(rows, xCols, nil)
+	y = mat.NewDense(rows, yCols, nil)
+
+	for i := 0; i < rows; i++ {
+		for j := 0; j < xCols; j++ {
+			x.Set(i, j, r.Float64())
+		}
+		y.Set(i, r.Intn(yCols), 1)
+	}
+
+	return x, y
+}
+
+func copyNetwork(t *testing.T, n *nn.FeedForwardNetwork) *nn.FeedForwardNetwork {
+	var buf bytes.Buffer
+	require.NoError(t, n.Save(&buf))
+
+	result, err := nn.Load(&buf)
+	require.NoError(t, err)
+	return result
+}
+
+func train(x, y *mat.Dense, loss nn.Loss, net nn.Value, settings ...Setting) error {
+	_, err := SGD(x, y, loss, net, settings...)
+	return err
+}
@@ -0,0 +1,132 @@
+package sgd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/rosshemsley/gonn/nn"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Summary is the mean and standard deviation of a score over the folds of a cross-validation.
+type Summary struct {
+	Mean   float64
+	StdDev float64
+}
+
+// CrossValidation reports the results of CrossValidate.
+type CrossValidation struct {
+	// Folds holds the training history of the network trained for each fold.
+	Folds []*History
+
+	// ValidationLoss summarizes the validation loss of each fold's final network.
+	ValidationLoss Summary
+
+	// Metrics summarizes each metric given by WithMetric in the same way.
+	Metrics map[string]Summary
+}
+
+// CrossValidate runs k-fold cross-validation. The rows are divided into k disjoint folds,
+// and for each fold a fresh network from buildNet is trained with SGD on the other folds
+// and validated on that fold.
+//
+// The settings are passed on to SGD. WithShuffledSplit and WithStratifiedSplit control
+// how the folds are made, while the validation set size is ignored.
+// Checkpointing and WithValidationData cannot be used.
+//
+// The score of each fold is taken from the final epoch, or from the best epoch when
+// early stopping restores the best parameters.
+func CrossValidate(x, y *mat.Dense, k int, buildNet func() nn.Value, loss nn.Loss, settings ...Setting) (*CrossValidation, error) {
+	cfg := initConfig(settings...)
+	if cfg.checkpointPath != "" || cfg.resumePath != "" {
+		return nil, errors.New("checkpoints cannot be used with cross-validation")
+	}
+	if cfg.xVal != nil || cfg.yVal != nil {
+		return nil, errors.New("validation data cannot be given for cross-validation")
+	}
+
+	xRows, _ := x.Dims()
+	yRows, _ := y.Dims()
+	if xRows != yRows {
+		return nil, fmt.Errorf("mismatch in dimensions: %d != %d", xRows, yRows)
+	}
+	if k < 2 || k > xRows {
+		return nil, fmt.Errorf("cannot make %d folds from %d rows", k, xRows)
+	}
+
+	folds := make([][]int, k)
+	for _, rows := range splitGroups(y, cfg, rand.New(rand.NewSource(cfg.seed))) {
+		for i, r := range rows {
+			f := i * k / len(rows)
+			folds[f] = append(folds[f], r)
+		}
+	}
+
+	result := &CrossValidation{
+		Folds:   make([]*History, k),
+		Metrics: make(map[string]Summary),
+	}
+	losses := make([]float64, k)
+	metrics := make(map[string][]float64)
+
+	for f := range folds {
+		if len(folds[f]) == 0 {
+			return nil, fmt.Errorf("fold %d is empty", f+1)
+		}
+
+		var trainRows []int
+		for g := range folds {
+			if g != f {
+				trainRows = append(trainRows, folds[g]...)
+			}
+		}
+
+		foldSettings := append(append([]Setting(nil), settings...),
+			WithSeed(cfg.seed+int64(f)),
+			WithValidationData(selectRows(x, folds[f]), selectRows(y, folds[f])),
+		)
+
+		history, err := SGD(selectRows(x, trainRows), selectRows(y, trainRows), loss, buildNet(), foldSettings...)
+		if err != nil {
+			return nil, fmt.Errorf("fold %d: %s", f+1, err)
+		}
+		if len(history.Epochs) == 0 {
+			return nil, fmt.Errorf("fold %d: no epochs were run", f+1)
+		}
+
+		e := history.Epochs[len(history.Epochs)-1]
+		if cfg.earlyStopping {
+			e, _ = history.Best()
+		}
+
+		result.Folds[f] = history
+		losses[f] = e.ValidationLoss
+		for name, v := range e.Metrics {
+			metrics[name] = append(metrics[name], v)
+		}
+	}
+
+	result.ValidationLoss = summarize(losses)
+	for name, vs := range metrics {
+		result.Metrics[name] = summarize(vs)
+	}
+
+	return result, nil
+}
+
+// summarize returns the mean and (population) standard deviation of vs.
+func summarize(vs []float64) Summary {
+	var mean float64
+	for _, v := range vs {
+		mean += v / float64(len(vs))
+	}
+
+	var variance float64
+	for _, v := range vs {
+		variance += (v - mean) * (v - mean) / float64(len(vs))
+	}
+
+	return Summary{Mean: mean, StdDev: math.Sqrt(variance)}
+}
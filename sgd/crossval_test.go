@@ -0,0 +1,73 @@
+package sgd
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rosshemsley/gonn/nn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestCrossValidate(t *testing.T) {
+	x, y := randomDataset(40, 3, 2)
+
+	var validationRows []int
+	rows := func(y, yHat *mat.Dense) float64 {
+		r, _ := y.Dims()
+		validationRows = append(validationRows, r)
+		return float64(r)
+	}
+
+	built := 0
+	buildNet := func() nn.Value {
+		built++
+		return nn.NewFeedForwardNetwork(nn.NewFullyConnectedLayer(3, 2))
+	}
+
+	cv, err := CrossValidate(x, y, 4, buildNet, nn.L2Loss,
+		WithEpochs(2),
+		WithBatchSize(5),
+		WithSeed(1),
+		WithShuffledSplit(),
+		WithMetric("rows", rows),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, built)
+	require.Len(t, cv.Folds, 4)
+	for _, r := range validationRows {
+		assert.Equal(t, 10, r)
+	}
+
+	var losses []float64
+	for _, h := range cv.Folds {
+		require.Len(t, h.Epochs, 2)
+		losses = append(losses, h.Epochs[1].ValidationLoss)
+	}
+	assert.InDelta(t, summarize(losses).Mean, cv.ValidationLoss.Mean, 1e-12)
+	assert.Equal(t, Summary{Mean: 10, StdDev: 0}, cv.Metrics["rows"])
+}
+
+func TestCrossValidateRejectsBadSettings(t *testing.T) {
+	x, y := randomDataset(10, 3, 2)
+	buildNet := func() nn.Value {
+		return nn.NewFeedForwardNetwork(nn.NewFullyConnectedLayer(3, 2))
+	}
+
+	_, err := CrossValidate(x, y, 1, buildNet, nn.L2Loss)
+	assert.Error(t, err)
+
+	_, err = CrossValidate(x, y, 11, buildNet, nn.L2Loss)
+	assert.Error(t, err)
+
+	_, err = CrossValidate(x, y, 2, buildNet, nn.L2Loss, WithValidationData(x, y))
+	assert.Error(t, err)
+}
+
+func TestSummarize(t *testing.T) {
+	s := summarize([]float64{1, 2, 3, 4})
+	assert.InDelta(t, 2.5, s.Mean, 1e-12)
+	assert.InDelta(t, math.Sqrt(1.25), s.StdDev, 1e-12)
+}
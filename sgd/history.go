@@ -0,0 +1,131 @@
+package sgd
+
+import (
+	"time"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Metric scores predictions yHat against targets y, e.g. classification accuracy.
+type Metric func(y, yHat *mat.Dense) float64
+
+// Epoch summarizes a single epoch of training.
+type Epoch struct {
+	// Epoch is the number of this epoch, starting from 1.
+	Epoch int
+
+	// TrainLoss is the mean loss over the training batches seen during the epoch.
+	TrainLoss float64
+
+	ValidationLoss float64
+
+	// Metrics holds the value of each metric given by WithMetric on the validation set.
+	Metrics map[string]float64
+
+	LearningRate float64
+	Duration     time.Duration
+}
+
+// Batch summarizes a single update of the network.
+type Batch struct {
+	Epoch int
+	Batch int
+	Loss  float64
+}
+
+// History records the progress of a training run.
+type History struct {
+	Epochs []Epoch
+
+	// RestoredEpoch is the epoch whose weights the network was restored to by early
+	// stopping, or 0 if they were not restored.
+	RestoredEpoch int
+}
+
+// Best returns the epoch with the lowest validation loss. If early stopping restored
+// the network to an earlier epoch, which only counts improvements larger than its
+// minDelta, that epoch is returned instead, so that it matches the weights of the network.
+func (h *History) Best() (Epoch, bool) {
+	if len(h.Epochs) == 0 {
+		return Epoch{}, false
+	}
+
+	if h.RestoredEpoch > 0 {
+		for _, e := range h.Epochs {
+			if e.Epoch == h.RestoredEpoch {
+				return e, true
+			}
+		}
+	}
+
+	best := h.Epochs[0]
+	for _, e := range h.Epochs[1:] {
+		if e.ValidationLoss < best.ValidationLoss {
+			best = e
+		}
+	}
+	return best, true
+}
+
+// Callbacks are notified as training progresses. Any of the callbacks may be nil.
+type Callbacks struct {
+	// OnBatchEnd is called after the network has been updated from each batch.
+	OnBatchEnd func(b Batch)
+
+	// OnEpochEnd is called at the end of each epoch. Returning true stops training.
+	OnEpochEnd func(e Epoch) (stop bool)
+
+	// OnTrainEnd is called once training has finished, including when stopped early.
+	OnTrainEnd func(h *History)
+}
+
+// WithCallbacks registers callbacks to be notified as training progresses.
+// It can be given more than once.
+func WithCallbacks(cb Callbacks) Setting {
+	return func(c *Config) {
+		c.callbacks = append(c.callbacks, cb)
+	}
+}
+
+// WithMetric evaluates the given metric on the validation set at the end of every epoch.
+// The result is recorded under name in the History.
+func WithMetric(name string, m Metric) Setting {
+	return func(c *Config) {
+		c.metricNames = append(c.metricNames, name)
+		c.metrics = append(c.metrics, m)
+	}
+}
+
+func (c Config) batchEnd(b Batch) {
+	for _, cb := range c.callbacks {
+		if cb.OnBatchEnd != nil {
+			cb.OnBatchEnd(b)
+		}
+	}
+}
+
+func (c Config) epochEnd(e Epoch) bool {
+	stop := false
+	for _, cb := range c.callbacks {
+		if cb.OnEpochEnd != nil && cb.OnEpochEnd(e) {
+			stop = true
+		}
+	}
+	return stop
+}
+
+func (c Config) trainEnd(h *History) {
+	for _, cb := range c.callbacks {
+		if cb.OnTrainEnd != nil {
+			cb.OnTrainEnd(h)
+		}
+	}
+}
+
+func (c Config) evaluateMetrics(y, yHat *mat.Dense) map[string]float64 {
+	result := make(map[string]float64, len(c.metrics))
+	for i, m := range c.metrics {
+		result[c.metricNames[i]] = m(y, yHat)
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package sgd
+
+import (
+	"testing"
+
+	"github.com/rosshemsley/gonn/nn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestHistoryAndCallbacks(t *testing.T) {
+	x, y := randomDataset(50, 3, 2)
+	net := nn.NewFeedForwardNetwork(nn.NewFullyConnectedLayer(3, 2))
+
+	batches := 0
+	var epochs []int
+	var final *History
+
+	cb := Callbacks{
+		OnBatchEnd: func(b Batch) {
+			batches++
+		},
+		OnEpochEnd: func(e Epoch) bool {
+			epochs = append(epochs, e.Epoch)
+			return false
+		},
+		OnTrainEnd: func(h *History) {
+			final = h
+		},
+	}
+
+	rows := func(y, yHat *mat.Dense) float64 {
+		r, _ := yHat.Dims()
+		return float64(r)
+	}
+
+	history, err := SGD(x, y, nn.L2Loss, net,
+		WithEpochs(3),
+		WithBatchSize(10),
+		WithCallbacks(cb),
+		WithMetric("rows", rows),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{1, 2, 3}, epochs)
+	assert.True(t, batches >= 3)
+	assert.Equal(t, history, final)
+	require.Len(t, history.Epochs, 3)
+
+	for _, e := range history.Epochs {
+		assert.Equal(t, 5.0, e.Metrics["rows"])
+		assert.True(t, e.TrainLoss > 0)
+		assert.Equal(t, nn.LearningRate, e.LearningRate)
+	}
+}
+
+func TestCallbackStopsTraining(t *testing.T) {
+	x, y := randomDataset(20, 3, 2)
+	net := nn.NewFeedForwardNetwork(nn.NewFullyConnectedLayer(3, 2))
+
+	stopAfterTwo := Callbacks{
+		OnEpochEnd: func(e Epoch) bool {
+			return e.Epoch == 2
+		},
+	}
+
+	history, err := SGD(x, y, nn.L2Loss, net, WithEpochs(10), WithCallbacks(stopAfterTwo))
+	require.NoError(t, err)
+	assert.Len(t, history.Epochs, 2)
+}
+
+func TestEarlyStoppingRestoresBestWeights(t *testing.T) {
+	x, y := randomDataset(40, 3, 2)
+	net := nn.NewFeedForwardNetwork(nn.NewFullyConnectedLayer(3, 2))
+
+	// A learning rate this large makes the validation loss diverge after the first epoch.
+	var best []*mat.Dense
+	snapshotFirst := Callbacks{
+		OnEpochEnd: func(e Epoch) bool {
+			if e.Epoch == 1 {
+				best = copySnapshot(snapshotOf(net))
+			}
+			return false
+		},
+	}
+
+	history, err := SGD(x, y, nn.L2Loss, net,
+		WithEpochs(20),
+		WithLearningRate(50),
+		WithEarlyStopping(2, 0),
+		WithCallbacks(snapshotFirst),
+	)
+	require.NoError(t, err)
+
+	b, ok := history.Best()
+	require.True(t, ok)
+	require.Equal(t, 1, b.Epoch)
+	assert.Len(t, history.Epochs, 3)
+
+	for i, w := range snapshotOf(net) {
+		assert.Equal(t, best[i].RawMatrix().Data, w.RawMatrix().Data)
+	}
+}
+
+func TestBestMatchesRestoredWeights(t *testing.T) {
+	x, y := randomDataset(40, 3, 2)
+	net := nn.NewFeedForwardNetwork(nn.NewFullyConnectedLayer(3, 2))
+
+	// No improvement is large enough to count, so the first epoch is restored
+	// even if the validation loss falls slightly after it.
+	var first []*mat.Dense
+	snapshotFirst := Callbacks{
+		OnEpochEnd: func(e Epoch) bool {
+			if e.Epoch == 1 {
+				first = copySnapshot(snapshotOf(net))
+			}
+			return false
+		},
+	}
+
+	history, err := SGD(x, y, nn.L2Loss, net,
+		WithEpochs(20),
+		WithLearningRate(0.01),
+		WithEarlyStopping(3, 1e6),
+		WithCallbacks(snapshotFirst),
+	)
+	require.NoError(t, err)
+	require.Len(t, history.Epochs, 4)
+	assert.Equal(t, 1, history.RestoredEpoch)
+
+	b, ok := history.Best()
+	require.True(t, ok)
+	assert.Equal(t, 1, b.Epoch)
+	for i, w := range snapshotOf(net) {
+		assert.Equal(t, first[i].RawMatrix().Data, w.RawMatrix().Data)
+	}
+
+	// Without early stopping, the epoch with the lowest loss is the best.
+	h := History{Epochs: []Epoch{{Epoch: 1, ValidationLoss: 2}, {Epoch: 2, ValidationLoss: 1}}}
+	b, _ = h.Best()
+	assert.Equal(t, 2, b.Epoch)
+
+	h.RestoredEpoch = 1
+	b, _ = h.Best()
+	assert.Equal(t, 1, b.Epoch)
+}
@@ -0,0 +1,139 @@
+package sgd
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/rosshemsley/gonn/nn"
+	"gonum.org/v1/gonum/mat"
+)
+
+// WithWorkers splits each batch across n goroutines, each computing the gradient of
+// its share of the batch on its own copy of the network. The gradients are combined
+// and a single update is made to the network, as if the batch had been processed whole.
+//
+// Copies are made with nn.Clone, so every layer of the network must be able to be saved.
+// Layers that compute statistics over the batch, such as batch normalization, see only
+// their share of it; their state is averaged over the copies after each update.
+// Each copy's dropout layers are seeded separately, see WithSeed.
+// Defaults to 1, which trains on the calling goroutine.
+func WithWorkers(n int) Setting {
+	if n < 1 {
+		n = 1
+	}
+	return func(c *Config) {
+		c.workers = n
+	}
+}
+
+// replicas holds the network being trained, along with the copies used by the
+// other workers. The network itself is the first replica.
+type replicas struct {
+	nets      []nn.Value
+	params    [][]*nn.Parameter
+	snapshots [][]*mat.Dense
+}
+
+func newReplicas(net nn.Value, n int) (*replicas, error) {
+	r := &replicas{nets: []nn.Value{net}}
+	for i := 1; i < n; i++ {
+		clone, err := nn.Clone(net)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy network for worker %d: %s", i, err)
+		}
+		r.nets = append(r.nets, clone)
+	}
+
+	for _, v := range r.nets {
+		r.params = append(r.params, v.Parameters())
+		r.snapshots = append(r.snapshots, snapshotOf(v))
+	}
+
+	return r, nil
+}
+
+func (r *replicas) SetTrainingEnabled(b bool) {
+	for _, v := range r.nets {
+		v.SetTrainingEnabled(b)
+	}
+}
+
+// seed seeds the layers of each replica that draw random numbers (see nn.Seeder)
+// from rng, giving each replica different seeds.
+func (r *replicas) seed(rng *rand.Rand) {
+	for _, v := range r.nets {
+		if s, ok := v.(nn.Seeder); ok {
+			s.Seed(rng.Int63())
+		}
+	}
+}
+
+// gradient leaves the gradient of the mean loss over the batch in the parameters of the
+// network, and returns the loss. Each worker is given a contiguous share of the rows.
+func (r *replicas) gradient(x, y *mat.Dense, loss nn.Loss) float64 {
+	rows, _ := x.Dims()
+	n := len(r.nets)
+	if n > rows {
+		n = rows
+	}
+
+	state := nn.StateOf(r.nets[0])
+	for i := 1; i < n; i++ {
+		for j, w := range r.snapshots[i] {
+			w.Copy(r.snapshots[0][j])
+		}
+	}
+
+	losses := make([]float64, n)
+	shares := make([]float64, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		start, end := i*rows/n, (i+1)*rows/n
+		shares[i] = float64(end-start) / float64(rows)
+
+		wg.Add(1)
+		go func(i int, x, y *mat.Dense) {
+			defer wg.Done()
+			yHat := r.nets[i].Forwards(x)
+			l, grad := loss(y, yHat)
+
+			nn.ZeroGradients(r.params[i])
+			r.nets[i].Backwards(grad)
+			losses[i] = l
+		}(i, sliceRows(x, start, end), sliceRows(y, start, end))
+	}
+	wg.Wait()
+
+	var total float64
+	for i := 0; i < n; i++ {
+		total += losses[i] * shares[i]
+	}
+
+	// The loss of each share is its mean, so weight each gradient by the size of its share.
+	for j, p := range r.params[0] {
+		p.Grad.Scale(shares[0], p.Grad)
+		for i := 1; i < n; i++ {
+			g := r.params[i][j].Grad
+			g.Scale(shares[i], g)
+			p.Grad.Add(p.Grad, g)
+		}
+	}
+
+	for j, s := range state {
+		s.Scale(shares[0], s)
+		for i := 1; i < n; i++ {
+			other := r.snapshots[i][len(r.params[i])+j]
+			other.Scale(shares[i], other)
+			s.Add(s, other)
+		}
+	}
+
+	return total
+}
+
+// sliceRows returns a view of rows [start, end) of m.
+func sliceRows(m *mat.Dense, start, end int) *mat.Dense {
+	_, cols := m.Dims()
+	return m.Slice(start, end, 0, cols).(*mat.Dense)
+}
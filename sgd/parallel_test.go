@@ -0,0 +1,58 @@
+package sgd
+
+import (
+	"testing"
+
+	"github.com/rosshemsley/gonn/nn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkersMatchSerialTraining(t *testing.T) {
+	x, y := randomDataset(60, 3, 2)
+	initial := nn.NewFeedForwardNetwork(
+		nn.NewFullyConnectedLayer(3, 4),
+		nn.NewDenseLayer(4, 2),
+	)
+
+	settings := func(workers int) []Setting {
+		return []Setting{
+			WithEpochs(3),
+			WithBatchSize(10),
+			WithSeed(5),
+			WithOptimizer(nn.NewMomentum(0.1, 0.9)),
+			WithWorkers(workers),
+		}
+	}
+
+	serial := copyNetwork(t, initial)
+	serialHistory, err := SGD(x, y, nn.L2Loss, serial, settings(1)...)
+	require.NoError(t, err)
+
+	parallel := copyNetwork(t, initial)
+	parallelHistory, err := SGD(x, y, nn.L2Loss, parallel, settings(4)...)
+	require.NoError(t, err)
+
+	for i, w := range snapshotOf(serial) {
+		assert.InDeltaSlice(t, w.RawMatrix().Data, snapshotOf(parallel)[i].RawMatrix().Data, 1e-9)
+	}
+	for i, e := range serialHistory.Epochs {
+		assert.InDelta(t, e.TrainLoss, parallelHistory.Epochs[i].TrainLoss, 1e-9)
+	}
+}
+
+func TestWorkersAverageState(t *testing.T) {
+	x, y := randomDataset(8, 3, 3)
+	net := nn.NewBatchNormLayer(3)
+
+	r, err := newReplicas(net, 2)
+	require.NoError(t, err)
+	r.SetTrainingEnabled(true)
+	r.gradient(x, y, nn.L2Loss)
+
+	// Each worker moves its running mean towards the mean of its half of the batch,
+	// so the average matches a single update with the mean of the whole batch.
+	expected := nn.NewBatchNormLayer(3)
+	expected.Forwards(x)
+	assert.InDeltaSlice(t, expected.State()[0].RawMatrix().Data, net.State()[0].RawMatrix().Data, 1e-12)
+}
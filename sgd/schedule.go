@@ -0,0 +1,191 @@
+package sgd
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/rosshemsley/gonn/nn"
+)
+
+// Schedule determines the learning rate used for each epoch of training.
+type Schedule interface {
+	// LearningRate returns the learning rate for the given (zero-indexed) epoch,
+	// where base is the learning rate training started with.
+	LearningRate(epoch int, base float64) float64
+}
+
+// ValidationObserver is implemented by schedules that adapt to the validation loss.
+// SGD reports the validation loss to the schedule at the end of every epoch.
+type ValidationObserver interface {
+	ObserveValidationLoss(loss float64)
+}
+
+type constantSchedule struct{}
+
+func (constantSchedule) LearningRate(epoch int, base float64) float64 {
+	return base
+}
+
+// StepDecay multiplies the learning rate by gamma every stepSize epochs.
+type StepDecay struct {
+	stepSize int
+	gamma    float64
+}
+
+// NewStepDecay returns a StepDecay schedule. It panics if stepSize is less than 1.
+func NewStepDecay(stepSize int, gamma float64) *StepDecay {
+	if stepSize < 1 {
+		panic(fmt.Sprintf("step size must be at least 1, got %d", stepSize))
+	}
+	return &StepDecay{stepSize: stepSize, gamma: gamma}
+}
+
+func (s *StepDecay) LearningRate(epoch int, base float64) float64 {
+	return base * math.Pow(s.gamma, float64(epoch/s.stepSize))
+}
+
+// ExponentialDecay multiplies the learning rate by gamma every epoch.
+type ExponentialDecay struct {
+	gamma float64
+}
+
+func NewExponentialDecay(gamma float64) *ExponentialDecay {
+	return &ExponentialDecay{gamma: gamma}
+}
+
+func (s *ExponentialDecay) LearningRate(epoch int, base float64) float64 {
+	return base * math.Pow(s.gamma, float64(epoch))
+}
+
+// CosineAnnealing anneals the learning rate from the base rate down to a minimum
+// along a cosine curve, restarting every period epochs (SGDR).
+// After each restart the period is multiplied by periodMultiplier.
+type CosineAnnealing struct {
+	period           int
+	periodMultiplier int
+	minLearningRate  float64
+}
+
+// NewCosineAnnealing returns a CosineAnnealing schedule. It panics if period is less than 1.
+func NewCosineAnnealing(period, periodMultiplier int, minLearningRate float64) *CosineAnnealing {
+	if period < 1 {
+		panic(fmt.Sprintf("period must be at least 1, got %d", period))
+	}
+	return &CosineAnnealing{
+		period:           period,
+		periodMultiplier: periodMultiplier,
+		minLearningRate:  minLearningRate,
+	}
+}
+
+func (s *CosineAnnealing) LearningRate(epoch int, base float64) float64 {
+	t, period := epoch, s.period
+	for t >= period {
+		t -= period
+		if s.periodMultiplier > 1 {
+			period *= s.periodMultiplier
+		}
+	}
+
+	progress := float64(t) / float64(period)
+	return s.minLearningRate + (base-s.minLearningRate)*(1+math.Cos(math.Pi*progress))/2
+}
+
+// LinearWarmup ramps the learning rate linearly up to the base rate over the
+// given number of epochs, then hands over to the next schedule.
+type LinearWarmup struct {
+	epochs int
+	next   Schedule
+}
+
+func NewLinearWarmup(epochs int, next Schedule) *LinearWarmup {
+	if next == nil {
+		next = constantSchedule{}
+	}
+	return &LinearWarmup{epochs: epochs, next: next}
+}
+
+func (s *LinearWarmup) LearningRate(epoch int, base float64) float64 {
+	if epoch < s.epochs {
+		return base * float64(epoch+1) / float64(s.epochs)
+	}
+	return s.next.LearningRate(epoch-s.epochs, base)
+}
+
+func (s *LinearWarmup) ObserveValidationLoss(loss float64) {
+	if o, ok := s.next.(ValidationObserver); ok {
+		o.ObserveValidationLoss(loss)
+	}
+}
+
+// ReduceOnPlateau multiplies the learning rate by factor whenever the validation
+// loss has not improved for patience epochs in a row, counting in the same way as
+// WithEarlyStopping. The rate never drops below minLearningRate.
+type ReduceOnPlateau struct {
+	factor          float64
+	patience        int
+	minLearningRate float64
+
+	best       float64
+	badEpochs  int
+	reductions int
+}
+
+func NewReduceOnPlateau(factor float64, patience int, minLearningRate float64) *ReduceOnPlateau {
+	return &ReduceOnPlateau{
+		factor:          factor,
+		patience:        patience,
+		minLearningRate: minLearningRate,
+		best:            math.Inf(1),
+	}
+}
+
+func (s *ReduceOnPlateau) LearningRate(epoch int, base float64) float64 {
+	return math.Max(base*math.Pow(s.factor, float64(s.reductions)), s.minLearningRate)
+}
+
+func (s *ReduceOnPlateau) ObserveValidationLoss(loss float64) {
+	if loss < s.best {
+		s.best = loss
+		s.badEpochs = 0
+		return
+	}
+
+	s.badEpochs++
+	if s.badEpochs >= s.patience {
+		s.reductions++
+		s.badEpochs = 0
+	}
+}
+
+type reduceOnPlateauState struct {
+	Best       float64
+	BadEpochs  int
+	Reductions int
+}
+
+func (s *ReduceOnPlateau) MarshalBinary() ([]byte, error) {
+	return nn.MarshalGob(reduceOnPlateauState{
+		Best:       s.best,
+		BadEpochs:  s.badEpochs,
+		Reductions: s.reductions,
+	})
+}
+
+func (s *ReduceOnPlateau) UnmarshalBinary(data []byte) error {
+	var state reduceOnPlateauState
+	if err := nn.UnmarshalGob(data, &state); err != nil {
+		return err
+	}
+
+	s.best, s.badEpochs, s.reductions = state.Best, state.BadEpochs, state.Reductions
+	return nil
+}
+
+func (s *LinearWarmup) MarshalBinary() ([]byte, error) {
+	return marshalState(s.next)
+}
+
+func (s *LinearWarmup) UnmarshalBinary(data []byte) error {
+	return unmarshalState(s.next, data)
+}
@@ -0,0 +1,59 @@
+package sgd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepDecay(t *testing.T) {
+	s := NewStepDecay(2, 0.5)
+
+	assert.InDelta(t, 1.0, s.LearningRate(0, 1), 1e-9)
+	assert.InDelta(t, 1.0, s.LearningRate(1, 1), 1e-9)
+	assert.InDelta(t, 0.5, s.LearningRate(2, 1), 1e-9)
+	assert.InDelta(t, 0.25, s.LearningRate(5, 1), 1e-9)
+}
+
+func TestCosineAnnealingRestarts(t *testing.T) {
+	s := NewCosineAnnealing(4, 2, 0.1)
+
+	assert.InDelta(t, 1.0, s.LearningRate(0, 1), 1e-9)
+	assert.InDelta(t, 0.55, s.LearningRate(2, 1), 1e-9)
+	// The first restart happens after 4 epochs, the next after a further 8.
+	assert.InDelta(t, 1.0, s.LearningRate(4, 1), 1e-9)
+	assert.InDelta(t, 0.55, s.LearningRate(8, 1), 1e-9)
+	assert.InDelta(t, 1.0, s.LearningRate(12, 1), 1e-9)
+}
+
+func TestLinearWarmup(t *testing.T) {
+	s := NewLinearWarmup(4, NewExponentialDecay(0.5))
+
+	assert.InDelta(t, 0.25, s.LearningRate(0, 1), 1e-9)
+	assert.InDelta(t, 1.0, s.LearningRate(3, 1), 1e-9)
+	assert.InDelta(t, 1.0, s.LearningRate(4, 1), 1e-9)
+	assert.InDelta(t, 0.5, s.LearningRate(5, 1), 1e-9)
+}
+
+func TestReduceOnPlateau(t *testing.T) {
+	s := NewReduceOnPlateau(0.1, 2, 0.001)
+
+	for _, l := range []float64{1.0, 0.9, 0.95} {
+		s.ObserveValidationLoss(l)
+	}
+	assert.InDelta(t, 1.0, s.LearningRate(3, 1), 1e-9)
+
+	// The second epoch in a row without improvement reduces the rate.
+	s.ObserveValidationLoss(0.95)
+	assert.InDelta(t, 0.1, s.LearningRate(4, 1), 1e-9)
+
+	for _, l := range []float64{0.95, 0.95, 0.95, 0.95, 0.95, 0.95} {
+		s.ObserveValidationLoss(l)
+	}
+	assert.InDelta(t, 0.001, s.LearningRate(10, 1), 1e-9)
+}
+
+func TestSchedulesRejectInvalidPeriods(t *testing.T) {
+	assert.Panics(t, func() { NewStepDecay(0, 0.5) })
+	assert.Panics(t, func() { NewCosineAnnealing(0, 1, 0) })
+}
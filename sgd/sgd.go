@@ -3,8 +3,10 @@ package sgd
 import (
 	"errors"
 	"fmt"
-	"log"
+	"math"
 	"math/rand"
+	"os"
+	"time"
 
 	"github.com/rosshemsley/gonn/nn"
 	"gonum.org/v1/gonum/mat"
@@ -16,35 +18,126 @@ type Config struct {
 	numEpochs              int
 	batchSize              int
 	validationSetProprtion float64
+	shuffleSplit           bool
+	stratifySplit          bool
+	xVal, yVal             *mat.Dense
 	regularizationConstant float64
+	optimizer              nn.Optimizer
+	learningRate           float64
+	schedule               Schedule
+	seed                   int64
+	checkpointPath         string
+	checkpointInterval     int
+	resumePath             string
+	callbacks              []Callbacks
+	metricNames            []string
+	metrics                []Metric
+	earlyStopping          bool
+	patience               int
+	minDelta               float64
+	workers                int
 }
 
 type LossFunction func(X, Y *mat.Dense) *mat.Dense
 
 // SGD runs stochastic gradient descent on the given net.
-func SGD(x, y *mat.Dense, loss nn.Loss, net nn.Value, settings ...Setting) {
+// Returns the history of the losses and metrics seen at the end of each epoch.
+func SGD(x, y *mat.Dense, loss nn.Loss, net nn.Value, settings ...Setting) (*History, error) {
 	cfg := initConfig(settings...)
 
-	xTrain, yTrain, xVal, yVal := trainValidationSplit(x, y, cfg.validationSetProprtion)
+	params := net.Parameters()
+	snapshot := snapshotOf(net)
 
-	for epoch := 0; epoch < cfg.numEpochs; epoch++ {
-		net.SetTrainingEnabled(true)
+	state := newTrainingState(cfg.seed)
+	if cfg.resumePath != "" {
+		if _, err := os.Stat(cfg.resumePath); err == nil {
+			state, err = resume(cfg.resumePath, snapshot, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resume from checkpoint: %s", err)
+			}
+		}
+	}
+
+	// The split is made from the seed in the training state, so that it is the same
+	// when resuming from a checkpoint.
+	xTrain, yTrain, xVal, yVal, err := trainValidationSplit(x, y, cfg, rand.New(rand.NewSource(state.seed)))
+	if err != nil {
+		return nil, err
+	}
+
+	workers, err := newReplicas(net, cfg.workers)
+	if err != nil {
+		return nil, err
+	}
 
-		xBatches, yBatches := createShuffledBatches(xTrain, yTrain, cfg.batchSize)
+	for epoch := state.epoch; epoch < cfg.numEpochs; epoch++ {
+		start := time.Now()
+		lr := cfg.schedule.LearningRate(epoch, cfg.learningRate)
+		cfg.optimizer.SetLearningRate(lr)
+		workers.SetTrainingEnabled(true)
 
+		rng := rand.New(rand.NewSource(state.seed + int64(epoch)))
+		xBatches, yBatches := createShuffledBatches(rng, xTrain, yTrain, cfg.batchSize)
+		workers.seed(rng)
+
+		var trainLoss float64
+		var trainRows int
 		for i := range xBatches {
 			xBatch, yBatch := xBatches[i], yBatches[i]
-			yHat := net.Forwards(xBatch)
-			_, grad := loss(yBatch, yHat)
-			l2Regularize(net, cfg.regularizationConstant)
-			net.Backwards(grad)
+			l := workers.gradient(xBatch, yBatch, loss)
+
+			l2Regularize(net, params, cfg.regularizationConstant)
+			cfg.optimizer.Update(params)
+
+			rows, _ := xBatch.Dims()
+			trainLoss += l * float64(rows)
+			trainRows += rows
+			cfg.batchEnd(Batch{Epoch: epoch + 1, Batch: i + 1, Loss: l})
 		}
 
 		net.SetTrainingEnabled(false)
 		yValHat := net.Forwards(xVal)
 		j, _ := loss(yVal, yValHat)
-		log.Printf("Validation set loss: %f (epoch %d/%d)", j, epoch+1, cfg.numEpochs)
+
+		if o, ok := cfg.schedule.(ValidationObserver); ok {
+			o.ObserveValidationLoss(j)
+		}
+
+		state.observe(j, cfg.minDelta, snapshot)
+		e := Epoch{
+			Epoch:          state.epoch,
+			TrainLoss:      trainLoss / float64(trainRows),
+			ValidationLoss: j,
+			Metrics:        cfg.evaluateMetrics(yVal, yValHat),
+			LearningRate:   lr,
+			Duration:       time.Since(start),
+		}
+		state.history.Epochs = append(state.history.Epochs, e)
+		stop := cfg.epochEnd(e)
+		if cfg.earlyStopping && state.badEpochs >= cfg.patience {
+			stop = true
+		}
+
+		if cfg.checkpointPath != "" && (stop || state.epoch%cfg.checkpointInterval == 0 || state.epoch == cfg.numEpochs) {
+			if err := saveCheckpoint(cfg.checkpointPath, state, snapshot, cfg); err != nil {
+				return nil, fmt.Errorf("failed to save checkpoint: %s", err)
+			}
+		}
+
+		if stop {
+			break
+		}
 	}
+
+	if cfg.earlyStopping && state.bestSnapshot != nil {
+		if err := restoreSnapshot(snapshot, state.bestSnapshot); err != nil {
+			return nil, err
+		}
+		state.history.RestoredEpoch = state.bestEpoch
+	}
+
+	cfg.trainEnd(&state.history)
+	return &state.history, nil
 }
 
 func WithBatchSize(n int) Setting {
@@ -53,12 +146,41 @@ func WithBatchSize(n int) Setting {
 	}
 }
 
+// WithValidationSetSize sets the percentage of the rows held out for validation.
+// Defaults to 10. By default the validation set is taken from the last rows of the data.
 func WithValidationSetSize(percent int) Setting {
 	return func(c *Config) {
 		c.validationSetProprtion = float64(percent) / 100
 	}
 }
 
+// WithShuffledSplit shuffles the rows before holding out the validation set,
+// which should be used when the data is ordered, e.g. by label.
+// The shuffle is determined by the seed, see WithSeed.
+func WithShuffledSplit() Setting {
+	return func(c *Config) {
+		c.shuffleSplit = true
+	}
+}
+
+// WithStratifiedSplit holds out the same proportion of the rows of each class for validation,
+// so that both sets have the same balance of classes as the data.
+// The targets must be one-hot, and the class of each row is taken to be its largest column.
+func WithStratifiedSplit() Setting {
+	return func(c *Config) {
+		c.stratifySplit = true
+	}
+}
+
+// WithValidationData validates on the given data rather than holding out part of the training data.
+// All of the training data is used for training.
+func WithValidationData(x, y *mat.Dense) Setting {
+	return func(c *Config) {
+		c.xVal = x
+		c.yVal = y
+	}
+}
+
 func WithRegularizationConstant(v float64) Setting {
 	return func(c *Config) {
 		c.regularizationConstant = v
@@ -71,34 +193,188 @@ func WithEpochs(n int) Setting {
 	}
 }
 
-func l2Regularize(v nn.Value, regularizationConstant float64) {
+// WithOptimizer sets the optimizer used to update the network's parameters.
+// Defaults to plain gradient descent with nn.LearningRate.
+func WithOptimizer(o nn.Optimizer) Setting {
+	return func(c *Config) {
+		c.optimizer = o
+	}
+}
+
+// WithLearningRate sets the initial learning rate, overriding that of the optimizer.
+func WithLearningRate(lr float64) Setting {
+	return func(c *Config) {
+		c.learningRate = lr
+	}
+}
+
+// WithCheckpoint saves a checkpoint to path every n epochs, and after the final epoch.
+// A checkpoint contains the parameters of the network, the state of the optimizer
+// and the best parameters seen so far, see RestoreBest.
+func WithCheckpoint(path string, n int) Setting {
+	if n < 1 {
+		n = 1
+	}
+	return func(c *Config) {
+		c.checkpointPath = path
+		c.checkpointInterval = n
+	}
+}
+
+// WithResume resumes training from the checkpoint at path, if it exists.
+// The network and optimizer must be set up exactly as they were for the original run.
+// Typically this is the same path as given to WithCheckpoint.
+//
+// The batches are shuffled, and layers such as dropout are seeded, as they would have
+// been in the original run, so a resumed run reproduces an uninterrupted one exactly.
+func WithResume(path string) Setting {
+	return func(c *Config) {
+		c.resumePath = path
+	}
+}
+
+// WithEarlyStopping stops training once the validation loss has failed to improve
+// by more than minDelta for patience epochs in a row.
+// When training finishes, the network is restored to the parameters that gave the
+// best validation loss.
+func WithEarlyStopping(patience int, minDelta float64) Setting {
+	return func(c *Config) {
+		c.earlyStopping = true
+		c.patience = patience
+		c.minDelta = minDelta
+	}
+}
+
+// WithSeed seeds the shuffling of the training data, and the masks drawn by layers such
+// as dropout (see nn.Seeder), so that runs with the same seed, data and initial network
+// produce identical results. Defaults to the current time.
+// Both are derived from the seed afresh every epoch, so a run resumed from a checkpoint
+// makes the same draws as one that was not interrupted.
+func WithSeed(seed int64) Setting {
+	return func(c *Config) {
+		c.seed = seed
+	}
+}
+
+// WithSchedule sets how the learning rate changes from epoch to epoch.
+// Defaults to a constant learning rate.
+func WithSchedule(s Schedule) Setting {
+	return func(c *Config) {
+		c.schedule = s
+	}
+}
+
+// l2Regularize adds the gradient of the L2 penalty on the weights to the matching parameters.
+func l2Regularize(v nn.Value, params []*nn.Parameter, regularizationConstant float64) {
+	weights := make(map[*mat.Dense]bool)
 	for _, w := range v.Weights() {
-		deltaW := mat.DenseCopyOf(w)
-		deltaW.Scale(float64(-nn.LearningRate*regularizationConstant), deltaW)
-		w.Add(w, deltaW)
+		weights[w] = true
+	}
+
+	for _, p := range params {
+		if !weights[p.Value] {
+			continue
+		}
+		deltaW := mat.DenseCopyOf(p.Value)
+		deltaW.Scale(regularizationConstant, deltaW)
+		p.Grad.Add(p.Grad, deltaW)
 	}
 }
 
-func trainValidationSplit(x, y *mat.Dense, proportionInValidation float64) (xTrain, yTrain, xVal, yVal *mat.Dense) {
-	xRows, xCols := x.Dims()
-	yRows, yCols := y.Dims()
+// trainValidationSplit returns the training and validation sets according to cfg.
+// Each row of the data ends up in exactly one of the two sets.
+func trainValidationSplit(x, y *mat.Dense, cfg Config, rng *rand.Rand) (xTrain, yTrain, xVal, yVal *mat.Dense, err error) {
+	xRows, _ := x.Dims()
+	yRows, _ := y.Dims()
 	if xRows != yRows {
-		panic(fmt.Sprintf("mismatch in dimensions: %d != %d", xRows, yRows))
+		return nil, nil, nil, nil, fmt.Errorf("mismatch in dimensions: %d != %d", xRows, yRows)
 	}
 
-	nRowsValidation := int(proportionInValidation * float64(xRows))
-	nRowsTrain := xRows - nRowsValidation
+	if cfg.xVal != nil || cfg.yVal != nil {
+		if cfg.xVal == nil || cfg.yVal == nil {
+			return nil, nil, nil, nil, errors.New("validation data must include both inputs and targets")
+		}
+		xValRows, _ := cfg.xVal.Dims()
+		yValRows, _ := cfg.yVal.Dims()
+		if xValRows != yValRows {
+			return nil, nil, nil, nil, fmt.Errorf("mismatch in validation dimensions: %d != %d", xValRows, yValRows)
+		}
+		return x, y, cfg.xVal, cfg.yVal, nil
+	}
 
-	xTrain = mat.NewDense(nRowsTrain, xCols, x.RawMatrix().Data[0:nRowsTrain*xCols])
-	yTrain = mat.NewDense(nRowsTrain, yCols, y.RawMatrix().Data[0:nRowsTrain*yCols])
+	var trainRows, valRows []int
+	for _, rows := range splitGroups(y, cfg, rng) {
+		nTrain := len(rows) - int(math.Round(cfg.validationSetProprtion*float64(len(rows))))
+		trainRows = append(trainRows, rows[:nTrain]...)
+		valRows = append(valRows, rows[nTrain:]...)
+	}
 
-	xVal = mat.NewDense(nRowsValidation, xCols, x.RawMatrix().Data[0:nRowsValidation*xCols])
-	yVal = mat.NewDense(nRowsValidation, yCols, y.RawMatrix().Data[0:nRowsValidation*yCols])
+	if len(trainRows) == 0 || len(valRows) == 0 {
+		return nil, nil, nil, nil, fmt.Errorf("cannot split %d rows into training and validation sets", xRows)
+	}
 
-	return
+	return selectRows(x, trainRows), selectRows(y, trainRows), selectRows(x, valRows), selectRows(y, valRows), nil
 }
 
-func createShuffledBatches(x, y *mat.Dense, batchSize int) ([]*mat.Dense, []*mat.Dense) {
+// splitGroups returns the indices of the rows of y in the groups that should each be
+// split in the same proportions: one group per class when stratifying, otherwise a
+// single group. The rows of each group are shuffled if cfg asks for it.
+func splitGroups(y *mat.Dense, cfg Config, rng *rand.Rand) [][]int {
+	rows, _ := y.Dims()
+	var groups [][]int
+
+	if cfg.stratifySplit {
+		classes := make(map[int]int)
+		for i := 0; i < rows; i++ {
+			c := argmax(y.RawRowView(i))
+			g, ok := classes[c]
+			if !ok {
+				g = len(groups)
+				classes[c] = g
+				groups = append(groups, nil)
+			}
+			groups[g] = append(groups[g], i)
+		}
+	} else {
+		all := make([]int, rows)
+		for i := range all {
+			all[i] = i
+		}
+		groups = [][]int{all}
+	}
+
+	if cfg.shuffleSplit {
+		for _, g := range groups {
+			rng.Shuffle(len(g), func(i, j int) {
+				g[i], g[j] = g[j], g[i]
+			})
+		}
+	}
+
+	return groups
+}
+
+// selectRows returns a matrix made up of the given rows of m, in order.
+func selectRows(m *mat.Dense, rows []int) *mat.Dense {
+	_, cols := m.Dims()
+	data := make([]float64, 0, len(rows)*cols)
+	for _, r := range rows {
+		data = append(data, m.RawRowView(r)...)
+	}
+	return mat.NewDense(len(rows), cols, data)
+}
+
+func argmax(v []float64) int {
+	best := 0
+	for i := range v {
+		if v[i] > v[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+func createShuffledBatches(rng *rand.Rand, x, y *mat.Dense, batchSize int) ([]*mat.Dense, []*mat.Dense) {
 	xRows, xCols := x.Dims()
 	yRows, yCols := y.Dims()
 	if xRows != yRows {
@@ -113,7 +389,7 @@ func createShuffledBatches(x, y *mat.Dense, batchSize int) ([]*mat.Dense, []*mat
 		ys[i] = y.RawRowView(i)
 	}
 
-	rand.Shuffle(len(xs), func(i, j int) {
+	rng.Shuffle(len(xs), func(i, j int) {
 		xs[i], xs[j] = xs[j], xs[i]
 		ys[i], ys[j] = ys[j], ys[i]
 	})
@@ -150,7 +426,7 @@ func createShuffledBatches(x, y *mat.Dense, batchSize int) ([]*mat.Dense, []*mat
 	return xBatches, yBatches
 }
 
-func sampleBatch(X, Y *mat.Dense, n int) (XSample, YSample *mat.Dense, err error) {
+func sampleBatch(rng *rand.Rand, X, Y *mat.Dense, n int) (XSample, YSample *mat.Dense, err error) {
 	xNRows, xNCols := X.Dims()
 	if n > xNRows {
 		return nil, nil, errors.New("training data must exceed batch size")
@@ -160,7 +436,7 @@ func sampleBatch(X, Y *mat.Dense, n int) (XSample, YSample *mat.Dense, err error
 
 	indices := make([]int, 0, n)
 	for len(indices) < n {
-		indices = append(indices, rand.Intn(xNRows))
+		indices = append(indices, rng.Intn(xNRows))
 	}
 
 	XSample = mat.NewDense(n, xNCols, nil)
@@ -180,9 +456,21 @@ func initConfig(settings ...Setting) Config {
 		batchSize:              1,
 		validationSetProprtion: 0.1,
 		regularizationConstant: 0.0005,
+		seed:                   time.Now().UnixNano(),
+		checkpointInterval:     1,
+		workers:                1,
 	}
 	for _, s := range settings {
 		s(&cfg)
 	}
+	if cfg.optimizer == nil {
+		cfg.optimizer = nn.NewSGD(nn.LearningRate)
+	}
+	if cfg.learningRate == 0 {
+		cfg.learningRate = cfg.optimizer.LearningRate()
+	}
+	if cfg.schedule == nil {
+		cfg.schedule = constantSchedule{}
+	}
 	return cfg
 }
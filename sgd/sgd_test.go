@@ -0,0 +1,110 @@
+package sgd
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/rosshemsley/gonn/nn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestSeededRunsAreIdentical(t *testing.T) {
+	x, y := randomDataset(60, 3, 2)
+
+	run := func() *History {
+		rng := rand.New(rand.NewSource(3))
+		net := nn.NewFeedForwardNetwork(
+			nn.NewFullyConnectedLayer(3, 4, nn.WithRand(rng)),
+			nn.NewDropoutLayer(0.2, nn.WithRand(rng)),
+			nn.NewDenseLayer(4, 2, nn.WithRand(rng)),
+		)
+
+		history, err := SGD(x, y, nn.L2Loss, net, WithEpochs(3), WithBatchSize(8), WithSeed(11))
+		require.NoError(t, err)
+		return history
+	}
+
+	a, b := run(), run()
+	require.Len(t, a.Epochs, 3)
+	for i, e := range a.Epochs {
+		assert.Equal(t, e.TrainLoss, b.Epochs[i].TrainLoss)
+		assert.Equal(t, e.ValidationLoss, b.Epochs[i].ValidationLoss)
+	}
+}
+
+func TestTrainValidationSplitIsDisjoint(t *testing.T) {
+	x := mat.NewDense(10, 1, []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	y := mat.DenseCopyOf(x)
+
+	for _, shuffle := range []bool{false, true} {
+		cfg := initConfig(WithValidationSetSize(30))
+		cfg.shuffleSplit = shuffle
+
+		xTrain, yTrain, xVal, yVal, err := trainValidationSplit(x, y, cfg, rand.New(rand.NewSource(1)))
+		require.NoError(t, err)
+		assert.Equal(t, xTrain.RawMatrix().Data, yTrain.RawMatrix().Data)
+		assert.Equal(t, xVal.RawMatrix().Data, yVal.RawMatrix().Data)
+
+		seen := make(map[float64]int)
+		for _, v := range append(xTrain.RawMatrix().Data, xVal.RawMatrix().Data...) {
+			seen[v]++
+		}
+		assert.Len(t, seen, 10)
+		assert.Len(t, xVal.RawMatrix().Data, 3)
+
+		if !shuffle {
+			assert.Equal(t, []float64{7, 8, 9}, xVal.RawMatrix().Data)
+		}
+	}
+}
+
+func TestStratifiedSplit(t *testing.T) {
+	// 80 rows of class 0 followed by 20 rows of class 1.
+	x := mat.NewDense(100, 1, nil)
+	y := mat.NewDense(100, 2, nil)
+	for i := 0; i < 100; i++ {
+		x.Set(i, 0, float64(i))
+		if i < 80 {
+			y.Set(i, 0, 1)
+		} else {
+			y.Set(i, 1, 1)
+		}
+	}
+
+	cfg := initConfig(WithValidationSetSize(10), WithStratifiedSplit(), WithShuffledSplit())
+	_, yTrain, _, yVal, err := trainValidationSplit(x, y, cfg, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+
+	count := func(m *mat.Dense, class int) int {
+		rows, _ := m.Dims()
+		n := 0
+		for i := 0; i < rows; i++ {
+			if m.At(i, class) == 1 {
+				n++
+			}
+		}
+		return n
+	}
+
+	assert.Equal(t, 8, count(yVal, 0))
+	assert.Equal(t, 2, count(yVal, 1))
+	assert.Equal(t, 72, count(yTrain, 0))
+	assert.Equal(t, 18, count(yTrain, 1))
+}
+
+func TestWithValidationData(t *testing.T) {
+	x, y := randomDataset(20, 3, 2)
+	xVal, yVal := randomDataset(5, 3, 2)
+
+	cfg := initConfig(WithValidationData(xVal, yVal))
+	xTrain, _, xv, _, err := trainValidationSplit(x, y, cfg, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	assert.Equal(t, x, xTrain)
+	assert.Equal(t, xVal, xv)
+
+	cfg = initConfig(WithValidationData(xVal, nil))
+	_, _, _, _, err = trainValidationSplit(x, y, cfg, rand.New(rand.NewSource(1)))
+	assert.Error(t, err)
+}